@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+const (
+	DefaultAPIKeyHeader = "X-API-Key"
+)
+
+type (
+	// APIKeyStore key不存在时返回 ErrInvalidCredentials
+	APIKeyStore interface {
+		Lookup(ctx context.Context, key string) (*Principal, error)
+	}
+	APIKeyAuthenticator struct {
+		Store      APIKeyStore
+		HeaderName string // 为空时使用 DefaultAPIKeyHeader
+		QueryName  string // 为空时不从查询参数读取
+	}
+	// MemoryAPIKeyStore 只保存key的sha256摘要
+	MemoryAPIKeyStore struct {
+		mu   sync.RWMutex
+		keys map[string]*Principal
+	}
+)
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	headerName := a.HeaderName
+	if headerName == "" {
+		headerName = DefaultAPIKeyHeader
+	}
+	key := r.Header.Get(headerName)
+	if key == "" && a.QueryName != "" {
+		key = r.URL.Query().Get(a.QueryName)
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	p, err := a.Store.Lookup(r.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrInvalidCredentials
+	}
+	p.Method = MethodAPIKey
+	return p, nil
+}
+
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{
+		keys: make(map[string]*Principal),
+	}
+}
+func (s *MemoryAPIKeyStore) Add(key string, p *Principal) {
+	s.mu.Lock()
+	s.keys[hashAPIKey(key)] = p
+	s.mu.Unlock()
+}
+func (s *MemoryAPIKeyStore) Remove(key string) {
+	s.mu.Lock()
+	delete(s.keys, hashAPIKey(key))
+	s.mu.Unlock()
+}
+func (s *MemoryAPIKeyStore) Lookup(_ context.Context, key string) (*Principal, error) {
+	s.mu.RLock()
+	p, ok := s.keys[hashAPIKey(key)]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	actP := *p
+	return &actP, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/real-web-world/bdk/json"
+)
+
+const (
+	DefaultJWKSReloadInterval = 30 * time.Second
+	claimRoles                = "roles"
+	claimScope                = "scope"
+	claimName                 = "name"
+)
+
+var (
+	// DefaultJWTAlgorithms 未配置 Algorithms 时允许的签名算法 不包含none
+	DefaultJWTAlgorithms = []string{
+		"HS256", "HS384", "HS512",
+		"RS256", "RS384", "RS512",
+		"ES256", "ES384", "ES512",
+	}
+	ErrUnknownKeyID = errors.New("unknown jwt key id")
+)
+
+type (
+	// JWTAuthenticator 校验 Authorization: Bearer 中的jwt
+	// 密钥来源 Keys(kid->密钥) 与 JWKSFile 可同时使用 JWKSFile 修改后自动重新加载以实现密钥轮换
+	JWTAuthenticator struct {
+		Algorithms []string
+		// Keys kid对应的密钥 HS为[]byte RS为*rsa.PublicKey ES为*ecdsa.PublicKey
+		// token未携带kid时使用key为""的密钥
+		Keys     map[string]any
+		JWKSFile string
+		// JWKSReloadInterval 检查JWKSFile修改时间的最小间隔 默认 DefaultJWKSReloadInterval
+		JWKSReloadInterval time.Duration
+		Issuer             string
+		Audience           string
+		Leeway             time.Duration
+		// AllowNoExpiration 为true时接受未携带exp的token 默认拒绝 避免轮换下线的密钥签发的token永久有效
+		AllowNoExpiration bool
+		// ClaimsMapper 自定义claims到Principal的映射 为空时使用 sub/name/roles/scope
+		ClaimsMapper func(claims jwt.MapClaims) (*Principal, error)
+
+		mu          sync.RWMutex
+		jwksKeys    map[string]any
+		jwksModTime time.Time
+		lastCheck   time.Time
+	}
+	jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+		K   string `json:"k"`
+	}
+)
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	tokenStr := BearerToken(r)
+	// 非jwt格式的Bearer令牌留给会话认证器处理
+	if strings.Count(tokenStr, ".") != 2 {
+		return nil, ErrNoCredentials
+	}
+	return a.Parse(tokenStr)
+}
+
+// Parse 校验jwt并转换为Principal
+func (a *JWTAuthenticator) Parse(tokenStr string) (*Principal, error) {
+	algorithms := a.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = DefaultJWTAlgorithms
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithLeeway(a.Leeway),
+	}
+	if a.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.Issuer))
+	}
+	if a.Audience != "" {
+		opts = append(opts, jwt.WithAudience(a.Audience))
+	}
+	if !a.AllowNoExpiration {
+		opts = append(opts, jwt.WithExpirationRequired())
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenStr, claims, a.keyFunc, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	mapper := a.ClaimsMapper
+	if mapper == nil {
+		mapper = DefaultClaimsMapper
+	}
+	p, err := mapper(claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	p.Method = MethodJWT
+	return p, nil
+}
+
+// DefaultClaimsMapper sub->ID name->Name roles->Roles scope(空格分隔)->Scopes
+func DefaultClaimsMapper(claims jwt.MapClaims) (*Principal, error) {
+	sub, err := claims.GetSubject()
+	if err != nil {
+		return nil, err
+	}
+	if sub == "" {
+		return nil, errors.New("jwt missing sub")
+	}
+	p := &Principal{
+		ID:     sub,
+		Claims: claims,
+	}
+	p.Name, _ = claims[claimName].(string)
+	switch roles := claims[claimRoles].(type) {
+	case []any:
+		for _, role := range roles {
+			if roleStr, ok := role.(string); ok {
+				p.Roles = append(p.Roles, roleStr)
+			}
+		}
+	case string:
+		p.Roles = strings.Fields(roles)
+	}
+	if scope, ok := claims[claimScope].(string); ok {
+		p.Scopes = strings.Fields(scope)
+	}
+	if exp, _ := claims.GetExpirationTime(); exp != nil {
+		p.ExpiresAt = exp.Time
+	}
+	return p, nil
+}
+
+func (a *JWTAuthenticator) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := a.lookupKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	// 防止用公钥作为HMAC密钥的算法混淆攻击
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if _, ok := key.([]byte); !ok {
+			return nil, jwt.ErrInvalidKeyType
+		}
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := key.(*rsa.PublicKey); !ok {
+			return nil, jwt.ErrInvalidKeyType
+		}
+	case *jwt.SigningMethodECDSA:
+		if _, ok := key.(*ecdsa.PublicKey); !ok {
+			return nil, jwt.ErrInvalidKeyType
+		}
+	default:
+		return nil, jwt.ErrInvalidKeyType
+	}
+	return key, nil
+}
+func (a *JWTAuthenticator) lookupKey(kid string) (any, error) {
+	if key, ok := a.Keys[kid]; ok {
+		return key, nil
+	}
+	if a.JWKSFile == "" {
+		return nil, ErrUnknownKeyID
+	}
+	if err := a.reloadJWKS(false); err != nil {
+		return nil, err
+	}
+	a.mu.RLock()
+	key, ok := a.jwksKeys[kid]
+	a.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	// 未知kid可能是刚轮换的新密钥 忽略检查间隔立即重新加载
+	if err := a.reloadJWKS(true); err != nil {
+		return nil, err
+	}
+	a.mu.RLock()
+	key, ok = a.jwksKeys[kid]
+	a.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	return key, nil
+}
+
+// ReloadJWKS 立即重新加载 JWKSFile
+func (a *JWTAuthenticator) ReloadJWKS() error {
+	return a.reloadJWKS(true)
+}
+func (a *JWTAuthenticator) reloadJWKS(force bool) error {
+	interval := a.JWKSReloadInterval
+	if interval <= 0 {
+		interval = DefaultJWKSReloadInterval
+	}
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !force && a.jwksKeys != nil && now.Sub(a.lastCheck) < interval {
+		return nil
+	}
+	a.lastCheck = now
+	info, err := os.Stat(a.JWKSFile)
+	if err != nil {
+		return err
+	}
+	if a.jwksKeys != nil && info.ModTime().Equal(a.jwksModTime) {
+		return nil
+	}
+	bts, err := os.ReadFile(a.JWKSFile)
+	if err != nil {
+		return err
+	}
+	keys, err := ParseJWKS(bts)
+	if err != nil {
+		return err
+	}
+	a.jwksKeys = keys
+	a.jwksModTime = info.ModTime()
+	return nil
+}
+
+// ParseJWKS 解析JWKS 支持 RSA EC(P-256/P-384/P-521) oct 用途为enc的密钥会被忽略
+func ParseJWKS(bts []byte) (map[string]any, error) {
+	set := jwks{}
+	if err := json.Unmarshal(bts, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, item := range set.Keys {
+		if item.Use == "enc" {
+			continue
+		}
+		key, err := item.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: %w", item.Kid, err)
+		}
+		keys[item.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64URLInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64URLInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBase64URLInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64URLInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(k.K, "="))
+	default:
+		return nil, fmt.Errorf("unsupported kty %s", k.Kty)
+	}
+}
+func decodeBase64URLInt(str string) (*big.Int, error) {
+	bts, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(str, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(bts), nil
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/real-web-world/bdk/json"
+)
+
+func TestJWTParse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacKey := []byte("secret")
+	claims := jwt.MapClaims{"sub": "42", "roles": []string{"admin"}, "exp": time.Now().Add(time.Hour).Unix()}
+	sign := func(method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		str, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return str
+	}
+	keys := map[string]any{"hs": hmacKey, "rs": &rsaKey.PublicKey, "es": &ecKey.PublicKey}
+	cases := []struct {
+		name       string
+		token      string
+		algorithms []string
+		allowNoExp bool
+		wantID     string
+	}{
+		{name: "hs256", token: sign(jwt.SigningMethodHS256, hmacKey, "hs", claims), wantID: "42"},
+		{name: "rs256", token: sign(jwt.SigningMethodRS256, rsaKey, "rs", claims), wantID: "42"},
+		{name: "es256", token: sign(jwt.SigningMethodES256, ecKey, "es", claims), wantID: "42"},
+		// 以公钥内容作为HMAC密钥签名 冒充RS签名的token
+		{name: "hs256 with rsa public key", token: sign(jwt.SigningMethodHS256, rsaPubDER, "rs", claims)},
+		{name: "hs256 with ec key", token: sign(jwt.SigningMethodHS256, []byte("x"), "es", claims)},
+		{name: "rs256 with hmac key", token: sign(jwt.SigningMethodRS256, rsaKey, "hs", claims)},
+		{name: "none", token: sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "hs", claims)},
+		{name: "algorithm not allowed", token: sign(jwt.SigningMethodHS256, hmacKey, "hs", claims), algorithms: []string{"RS256"}},
+		{name: "unknown kid", token: sign(jwt.SigningMethodHS256, hmacKey, "other", claims)},
+		{name: "expired", token: sign(jwt.SigningMethodHS256, hmacKey, "hs", jwt.MapClaims{"sub": "42", "exp": time.Now().Add(-time.Hour).Unix()})},
+		{name: "missing sub", token: sign(jwt.SigningMethodHS256, hmacKey, "hs", jwt.MapClaims{"name": "a", "exp": time.Now().Add(time.Hour).Unix()})},
+		{name: "missing exp", token: sign(jwt.SigningMethodHS256, hmacKey, "hs", jwt.MapClaims{"sub": "42", "roles": []string{"admin"}})},
+		{name: "missing exp allowed", token: sign(jwt.SigningMethodHS256, hmacKey, "hs", jwt.MapClaims{"sub": "42", "roles": []string{"admin"}}), allowNoExp: true, wantID: "42"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &JWTAuthenticator{Keys: keys, Algorithms: tc.algorithms, AllowNoExpiration: tc.allowNoExp}
+			p, err := a.Parse(tc.token)
+			if tc.wantID == "" {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("Parse() err = %v, want %v", err, ErrInvalidCredentials)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.ID != tc.wantID || p.Method != MethodJWT || len(p.Roles) != 1 {
+				t.Fatalf("Parse() = %+v", p)
+			}
+		})
+	}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	writeJWKS := func(path string, kid string, secret []byte) {
+		t.Helper()
+		bts, err := json.Marshal(jwks{Keys: []jwk{{Kty: "oct", Kid: kid, K: base64.RawURLEncoding.EncodeToString(secret)}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(path, bts, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(path, "k1", []byte("s1"))
+	a := &JWTAuthenticator{JWKSFile: path, JWKSReloadInterval: time.Hour}
+	token := func(kid string, secret []byte) string {
+		tk := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Hour).Unix()})
+		tk.Header["kid"] = kid
+		str, err := tk.SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return str
+	}
+	if _, err := a.Parse(token("k1", []byte("s1"))); err != nil {
+		t.Fatal(err)
+	}
+	// 新kid立即重新加载 不受检查间隔限制
+	writeJWKS(path, "k2", []byte("s2"))
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Parse(token("k2", []byte("s2"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Parse(token("k1", []byte("s1"))); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("rotated key err = %v", err)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	b64 := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name    string
+		key     jwk
+		wantErr bool
+		skipped bool
+	}{
+		{name: "rsa", key: jwk{Kty: "RSA", Kid: "a", N: b64(rsaKey.N), E: b64(big.NewInt(int64(rsaKey.E)))}},
+		{name: "enc ignored", key: jwk{Kty: "RSA", Kid: "a", Use: "enc"}, skipped: true},
+		{name: "unsupported curve", key: jwk{Kty: "EC", Kid: "a", Crv: "P-192"}, wantErr: true},
+		{name: "unsupported kty", key: jwk{Kty: "OKP", Kid: "a"}, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bts, _ := json.Marshal(jwks{Keys: []jwk{tc.key}})
+			keys, err := ParseJWKS(bts)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseJWKS() err = %v", err)
+			}
+			if _, ok := keys["a"]; !tc.wantErr && ok == tc.skipped {
+				t.Fatalf("ParseJWKS() keys = %v", keys)
+			}
+		})
+	}
+}
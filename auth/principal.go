@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/real-web-world/bdk/fastcurd"
+)
+
+// Method
+const (
+	MethodJWT     = "jwt"
+	MethodSession = "session"
+	MethodAPIKey  = "apiKey"
+)
+
+var (
+	// ErrNoCredentials 请求中没有该认证方式的凭证 继续尝试下一个认证器
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials 凭证无效或已过期
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+var _ fastcurd.Caller = (*Principal)(nil)
+
+type (
+	principalCtxKey struct{}
+	// Principal 已认证的调用方
+	Principal struct {
+		ID        string         `json:"id"`
+		Name      string         `json:"name,omitempty"`
+		Roles     []string       `json:"roles,omitempty"`
+		Scopes    []string       `json:"scopes,omitempty"`
+		Method    string         `json:"method"`
+		Claims    map[string]any `json:"claims,omitempty"`
+		ExpiresAt time.Time      `json:"expiresAt,omitempty"`
+	}
+	Authenticator interface {
+		Authenticate(r *http.Request) (*Principal, error)
+	}
+	AuthenticatorFunc func(r *http.Request) (*Principal, error)
+)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) {
+	return f(r)
+}
+
+func (p *Principal) GetCallerID() string {
+	return p.ID
+}
+func (p *Principal) GetCallerRoles() []string {
+	return p.Roles
+}
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// WithPrincipal 写入上下文 同时作为 fastcurd.Caller 供行级权限与脱敏使用
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	ctx = context.WithValue(ctx, principalCtxKey{}, p)
+	return fastcurd.WithCaller(ctx, p)
+}
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalCtxKey{}).(*Principal)
+	return p
+}
+
+// Chain 依次尝试各认证器 直到成功或返回 ErrNoCredentials ErrInvalidCredentials 以外的错误
+// 均失败时优先返回凭证无效的错误
+func Chain(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		var lastErr error = ErrNoCredentials
+		for _, authenticator := range authenticators {
+			p, err := authenticator.Authenticate(r)
+			switch {
+			case err == nil:
+				return p, nil
+			case errors.Is(err, ErrInvalidCredentials):
+				lastErr = err
+			case !errors.Is(err, ErrNoCredentials):
+				return nil, err
+			}
+		}
+		return nil, lastErr
+	})
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSessionCookie = "session"
+	HeaderAuthorization  = "Authorization"
+	bearerPrefix         = "Bearer "
+)
+
+type (
+	// SessionStore 不透明会话令牌存储 令牌不存在或过期时返回 ErrInvalidCredentials
+	SessionStore interface {
+		Get(ctx context.Context, token string) (*Principal, error)
+	}
+	SessionAuthenticator struct {
+		Store      SessionStore
+		CookieName string // 为空时使用 DefaultSessionCookie
+		HeaderName string // 为空时从 Authorization: Bearer 读取
+	}
+	MemorySessionStore struct {
+		mu       sync.RWMutex
+		sessions map[string]memorySession
+	}
+	memorySession struct {
+		principal *Principal
+		expireAt  time.Time
+	}
+)
+
+func (a *SessionAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token := a.token(r)
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	p, err := a.Store.Get(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrInvalidCredentials
+	}
+	p.Method = MethodSession
+	return p, nil
+}
+func (a *SessionAuthenticator) token(r *http.Request) string {
+	cookieName := a.CookieName
+	if cookieName == "" {
+		cookieName = DefaultSessionCookie
+	}
+	if cookie, err := r.Cookie(cookieName); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	if a.HeaderName != "" {
+		return r.Header.Get(a.HeaderName)
+	}
+	return BearerToken(r)
+}
+
+// BearerToken 从 Authorization 头中取出 Bearer 令牌
+func BearerToken(r *http.Request) string {
+	authorization := r.Header.Get(HeaderAuthorization)
+	if len(authorization) > len(bearerPrefix) && strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix) {
+		return strings.TrimSpace(authorization[len(bearerPrefix):])
+	}
+	return ""
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]memorySession),
+	}
+}
+
+// Set ttl为0时不过期
+func (s *MemorySessionStore) Set(token string, p *Principal, ttl time.Duration) {
+	session := memorySession{principal: p}
+	if ttl > 0 {
+		session.expireAt = time.Now().Add(ttl)
+	}
+	s.mu.Lock()
+	s.sessions[token] = session
+	s.mu.Unlock()
+}
+func (s *MemorySessionStore) Delete(token string) {
+	s.mu.Lock()
+	delete(s.sessions, token)
+	s.mu.Unlock()
+}
+func (s *MemorySessionStore) Get(_ context.Context, token string) (*Principal, error) {
+	s.mu.RLock()
+	session, ok := s.sessions[token]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	if !session.expireAt.IsZero() && time.Now().After(session.expireAt) {
+		s.Delete(token)
+		return nil, ErrInvalidCredentials
+	}
+	p := *session.principal
+	p.ExpiresAt = session.expireAt
+	return &p, nil
+}
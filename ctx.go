@@ -10,3 +10,13 @@ func IsCtxDone(c context.Context) bool {
 		return false
 	}
 }
+
+type reqIDCtxKey struct{}
+
+func WithReqID(ctx context.Context, reqID string) context.Context {
+	return context.WithValue(ctx, reqIDCtxKey{}, reqID)
+}
+func GetReqID(ctx context.Context) string {
+	reqID, _ := ctx.Value(reqIDCtxKey{}).(string)
+	return reqID
+}
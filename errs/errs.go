@@ -0,0 +1,171 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/real-web-world/bdk/fastcurd"
+	"github.com/real-web-world/bdk/i18n"
+)
+
+var (
+	registry   = make(map[fastcurd.Code]CodeInfo)
+	registryMu sync.RWMutex
+)
+
+type (
+	// CodeInfo 业务码对应的http状态码与默认公开消息(可为i18n消息id)
+	CodeInfo struct {
+		Code       fastcurd.Code
+		HTTPStatus int
+		Msg        string
+	}
+	// Error 业务错误
+	// Msg 为返回给调用方的公开消息(可为i18n消息id), Cause 为内部原因 仅在 Expose 时作为公开消息返回
+	// Data 为返回给调用方的数据 Meta 为内部上下文 只随 Cause 记录到日志
+	Error struct {
+		Code   fastcurd.Code
+		Status int
+		Msg    string
+		Args   []any
+		Cause  error
+		Data   any
+		Meta   map[string]any
+		Expose bool
+	}
+)
+
+func init() {
+	Register(fastcurd.CodeOk, http.StatusOK, "")
+	Register(fastcurd.CodeDefaultError, http.StatusBadRequest, "")
+	Register(fastcurd.CodeNoAuth, http.StatusForbidden, i18n.MsgNoAuth)
+	Register(fastcurd.CodeBadReq, http.StatusBadRequest, i18n.MsgBadReq)
+	Register(fastcurd.CodeValidError, http.StatusUnprocessableEntity, "")
+	Register(fastcurd.CodeNoLogin, http.StatusUnauthorized, i18n.MsgNoLogin)
+	Register(fastcurd.CodeServerError, http.StatusInternalServerError, i18n.MsgServerBad)
+	Register(fastcurd.CodeRateLimitError, http.StatusTooManyRequests, i18n.MsgReqFrequency)
+	Register(fastcurd.CodePayloadTooLarge, http.StatusRequestEntityTooLarge, i18n.MsgBodyTooLarge)
+	// 请求合法 仅没有数据被修改 不视为客户端错误
+	Register(fastcurd.CodeNoChange, http.StatusOK, i18n.MsgNoChange)
+}
+
+// Register 注册业务码 已存在时覆盖
+func Register(code fastcurd.Code, httpStatus int, msg string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[code] = CodeInfo{Code: code, HTTPStatus: httpStatus, Msg: msg}
+}
+func Lookup(code fastcurd.Code) (CodeInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	info, ok := registry[code]
+	return info, ok
+}
+
+// HTTPStatus 业务码对应的http状态码 未注册的错误码为500
+func HTTPStatus(code fastcurd.Code) int {
+	if info, ok := Lookup(code); ok {
+		return info.HTTPStatus
+	}
+	if code == fastcurd.CodeOk {
+		return http.StatusOK
+	}
+	return http.StatusInternalServerError
+}
+
+func New(code fastcurd.Code, msg string, args ...any) *Error {
+	return &Error{Code: code, Msg: msg, Args: args}
+}
+
+// Wrap 包装内部错误 cause不会返回给调用方
+func Wrap(cause error, code fastcurd.Code, msg string, args ...any) *Error {
+	return &Error{Code: code, Msg: msg, Args: args, Cause: cause}
+}
+
+// Internal 包装为服务器内部错误
+func Internal(cause error) *Error {
+	return Wrap(cause, fastcurd.CodeServerError, "")
+}
+
+// As 从错误链中取出*Error 请求体超限时为413 其余包装为服务器内部错误
+func As(err error) *Error {
+	var actErr *Error
+	if errors.As(err, &actErr) {
+		return actErr
+	}
+	if IsBodyTooLarge(err) {
+		return Wrap(err, fastcurd.CodePayloadTooLarge, "")
+	}
+	return Internal(err)
+}
+
+// IsBodyTooLarge 错误链中是否有 *http.MaxBytesError
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+func (e *Error) Error() string {
+	msg := e.Msg
+	if msg == "" {
+		if info, ok := Lookup(e.Code); ok {
+			msg = info.Msg
+		}
+	}
+	if e.Cause != nil {
+		return fmt.Sprintf("code=%d msg=%s: %v", e.Code, msg, e.Cause)
+	}
+	return fmt.Sprintf("code=%d msg=%s", e.Code, msg)
+}
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+func (e *Error) WithStatus(status int) *Error {
+	e.Status = status
+	return e
+}
+
+// WithData 设置返回给调用方的数据
+func (e *Error) WithData(data any) *Error {
+	e.Data = data
+	return e
+}
+
+// WithMeta 记录内部上下文 不会返回给调用方
+func (e *Error) WithMeta(key string, val any) *Error {
+	if e.Meta == nil {
+		e.Meta = make(map[string]any)
+	}
+	e.Meta[key] = val
+	return e
+}
+
+// WithExpose 允许将cause作为公开消息返回
+func (e *Error) WithExpose() *Error {
+	e.Expose = true
+	return e
+}
+
+// HTTPStatus 优先使用错误自身的状态码 其次取注册表
+func (e *Error) HTTPStatus() int {
+	if e.Status != 0 {
+		return e.Status
+	}
+	return HTTPStatus(e.Code)
+}
+
+// PublicMsg 返回给调用方的消息 按locale翻译
+func (e *Error) PublicMsg(locale string) string {
+	switch {
+	case e.Msg != "":
+		return i18n.T(locale, e.Msg, e.Args...)
+	case e.Expose && e.Cause != nil:
+		return i18n.TranslateErr(locale, e.Cause)
+	}
+	if info, ok := Lookup(e.Code); ok && info.Msg != "" {
+		return i18n.T(locale, info.Msg)
+	}
+	return i18n.CodeMsg(locale, int(e.Code))
+}
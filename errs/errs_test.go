@@ -0,0 +1,58 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/real-web-world/bdk/fastcurd"
+	"github.com/real-web-world/bdk/i18n"
+)
+
+func TestAs(t *testing.T) {
+	bizErr := New(fastcurd.CodeBadReq, "bad")
+	cases := []struct {
+		name       string
+		err        error
+		wantCode   fastcurd.Code
+		wantStatus int
+	}{
+		{name: "biz error", err: bizErr, wantCode: fastcurd.CodeBadReq, wantStatus: http.StatusBadRequest},
+		{name: "wrapped biz error", err: fmt.Errorf("ctx: %w", bizErr), wantCode: fastcurd.CodeBadReq, wantStatus: http.StatusBadRequest},
+		{name: "plain error", err: errors.New("db down"), wantCode: fastcurd.CodeServerError, wantStatus: http.StatusInternalServerError},
+		{name: "unregistered code", err: New(fastcurd.Code(9999), ""), wantCode: 9999, wantStatus: http.StatusInternalServerError},
+		{name: "explicit status", err: New(fastcurd.CodeBadReq, "").WithStatus(http.StatusConflict), wantCode: fastcurd.CodeBadReq, wantStatus: http.StatusConflict},
+		{name: "max bytes", err: fmt.Errorf("bind: %w", &http.MaxBytesError{Limit: 1}), wantCode: fastcurd.CodePayloadTooLarge, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := As(tc.err)
+			if got.Code != tc.wantCode || got.HTTPStatus() != tc.wantStatus {
+				t.Fatalf("As() = code %d status %d, want code %d status %d",
+					got.Code, got.HTTPStatus(), tc.wantCode, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPublicMsg(t *testing.T) {
+	cause := errors.New("secret dsn")
+	cases := []struct {
+		name string
+		err  *Error
+		want string
+	}{
+		{name: "format args", err: New(fastcurd.CodeBadReq, "order %d not found", 42), want: "order 42 not found"},
+		{name: "cause hidden", err: Internal(cause), want: i18n.T(i18n.LocaleEn, i18n.MsgServerBad)},
+		{name: "cause exposed", err: Wrap(cause, fastcurd.CodeBadReq, "").WithExpose(), want: "secret dsn"},
+		{name: "registered default", err: New(fastcurd.CodeNoLogin, ""), want: i18n.T(i18n.LocaleEn, i18n.MsgNoLogin)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.err.PublicMsg(i18n.LocaleEn); got != tc.want {
+				t.Fatalf("PublicMsg() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,8 @@ const (
 	CodeNoLogin
 	CodeServerError
 	CodeRateLimitError
+	CodePayloadTooLarge
+	CodeNoChange
 )
 
 const (
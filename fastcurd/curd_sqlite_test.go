@@ -0,0 +1,236 @@
+package fastcurd_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/real-web-world/bdk/fastcurd"
+	"github.com/real-web-world/bdk/fastcurd/curdtest"
+)
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存库每个连接独立 限制为单连接 保证 ListRecord 并发查询访问同一个库
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return db
+}
+
+func TestFastcurdSQLite(t *testing.T) {
+	db := openSQLite(t)
+	t.Cleanup(func() { _ = curdtest.DropTable(db) })
+	for _, c := range curdtest.Cases(db) {
+		if !t.Run(c.Name, func(t *testing.T) {
+			if err := c.Run(); err != nil {
+				t.Fatal(err)
+			}
+		}) {
+			break
+		}
+	}
+}
+
+type testCaller struct {
+	roles []string
+}
+
+func (c testCaller) GetCallerID() string {
+	return "1"
+}
+func (c testCaller) GetCallerRoles() []string {
+	return c.roles
+}
+
+type adminRecord struct {
+	curdtest.Record
+}
+
+func (m *adminRecord) GetMaskRules() []fastcurd.MaskRule {
+	return []fastcurd.MaskRule{
+		{Field: "phone", Masker: fastcurd.MaskPhone, VisibleRoles: []string{"admin"}},
+	}
+}
+
+// TestFmtListInheritsCaller 查询返回的记录继承查询时的ctx 拥有可见角色的调用方看到原值
+func TestFmtListInheritsCaller(t *testing.T) {
+	db := openSQLite(t)
+	if err := db.AutoMigrate(&adminRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = curdtest.DropTable(db) })
+	seed := &adminRecord{Record: curdtest.Record{Name: "a", Phone: "13812345678"}}
+	if err := db.Create(seed).Error; err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		roles []string
+		want  string
+	}{
+		{name: "admin", roles: []string{"admin"}, want: "13812345678"},
+		{name: "user", roles: []string{"user"}, want: "138****5678"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := fastcurd.WithCaller(context.Background(), testCaller{roles: tc.roles})
+			m := &adminRecord{Record: curdtest.Record{Base: fastcurd.Base{DB: db, Ctx: ctx}}}
+			list, err := fastcurd.ListByIDArr(m, []int64{seed.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			detail, err := fastcurd.GetDetailByID(m, seed.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := fastcurd.GetFmtList(list).([]any)[0].(map[string]any)["phone"]
+			if got != tc.want {
+				t.Fatalf("list phone = %v, want %v", got, tc.want)
+			}
+			got = fastcurd.GetMaskFmtDetail(detail).(map[string]any)["phone"]
+			if got != tc.want {
+				t.Fatalf("detail phone = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// scorePolicy 非admin只能读取score>=2的记录 且不能删除
+type scorePolicy struct{}
+
+func (scorePolicy) ReadScope(_ context.Context, caller fastcurd.Caller) func(db *gorm.DB) *gorm.DB {
+	if fastcurd.HasCallerRole(caller, "admin") {
+		return nil
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("score >= ?", 2)
+	}
+}
+func (scorePolicy) AllowEdit(context.Context, fastcurd.Caller, []int64) bool {
+	return true
+}
+func (scorePolicy) AllowDelete(_ context.Context, caller fastcurd.Caller, _ []int64) bool {
+	return fastcurd.HasCallerRole(caller, "admin")
+}
+
+type policyRecord struct {
+	curdtest.Record
+}
+
+func (m *policyRecord) GetPolicy() fastcurd.Policy {
+	return scorePolicy{}
+}
+
+// TestPolicyProvider 实现 PolicyProvider 的模型启用行级策略 未实现的模型不受限制
+func TestPolicyProvider(t *testing.T) {
+	db := openSQLite(t)
+	if err := db.AutoMigrate(&curdtest.Record{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = curdtest.DropTable(db) })
+	seeds := []*curdtest.Record{{Name: "a", Score: 1}, {Name: "b", Score: 2}, {Name: "c", Score: 3}}
+	if err := db.Create(seeds).Error; err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name      string
+		policy    bool
+		roles     []string
+		wantCount int64
+		wantDel   error
+	}{
+		{name: "no provider", roles: []string{"user"}, wantCount: 3},
+		{name: "policy user", policy: true, roles: []string{"user"}, wantCount: 2, wantDel: fastcurd.ErrPolicyDenied},
+		{name: "policy admin", policy: true, roles: []string{"admin"}, wantCount: 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := fastcurd.WithCaller(context.Background(), testCaller{roles: tc.roles})
+			var (
+				count int64
+				err   error
+			)
+			// admin 放在最后 删除成功不影响其他用例
+			if tc.policy {
+				m := &policyRecord{Record: curdtest.Record{Base: fastcurd.Base{DB: db, Ctx: ctx}}}
+				if _, count, err = fastcurd.ListRecord(m, 1, 10, nil, nil); err != nil {
+					t.Fatal(err)
+				}
+				_, err = fastcurd.DelByIDArr(m, []int64{seeds[0].ID})
+			} else {
+				m := &curdtest.Record{Base: fastcurd.Base{DB: db, Ctx: ctx}}
+				if _, count, err = fastcurd.ListRecord(m, 1, 10, nil, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if count != tc.wantCount {
+				t.Fatalf("count = %d, want %d", count, tc.wantCount)
+			}
+			if tc.policy && !errors.Is(err, tc.wantDel) {
+				t.Fatalf("delete err = %v, want %v", err, tc.wantDel)
+			}
+		})
+	}
+}
+
+type snowflakeRecord struct {
+	fastcurd.SnowflakeBase
+	Name string `json:"name" gorm:"size:64;not null;default:''"`
+}
+
+func (m *snowflakeRecord) TableName() string {
+	return "bdk_snowflake_record"
+}
+
+type idGenFunc func() (int64, error)
+
+func (f idGenFunc) NextID() (int64, error) {
+	return f()
+}
+
+// TestSnowflakeCreateRecord id生成失败时 BeforeCreate 返回错误 创建中止而不是panic
+func TestSnowflakeCreateRecord(t *testing.T) {
+	db := openSQLite(t)
+	if err := db.AutoMigrate(&snowflakeRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	errNoNode := errors.New("no node id")
+	cases := []struct {
+		name    string
+		gen     idGenFunc
+		wantID  int64
+		wantErr error
+	}{
+		{name: "assigned", gen: func() (int64, error) { return 1<<53 + 1, nil }, wantID: 1<<53 + 1},
+		{name: "generator error", gen: func() (int64, error) { return 0, errNoNode }, wantErr: errNoNode},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fastcurd.SetIDGenerator(tc.gen)
+			t.Cleanup(func() { fastcurd.SetIDGenerator(nil) })
+			m := &snowflakeRecord{SnowflakeBase: fastcurd.SnowflakeBase{DB: db, Ctx: context.Background()}}
+			record := &snowflakeRecord{Name: tc.name}
+			_, err := fastcurd.CreateRecord(m, &record)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("CreateRecord() err = %v, want %v", err, tc.wantErr)
+			}
+			if record.ID != tc.wantID {
+				t.Fatalf("id = %d, want %d", record.ID, tc.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Package curdtest 对任意 gorm 方言执行 fastcurd 的 AutoMigrate 与增删改查测试
+// 本包不依赖 testing 由调用方在测试中按顺序执行 Cases 遇到错误即停止
+//
+// SQLite 内存库见 fastcurd 包的 curd_sqlite_test.go 其他方言只需替换 Dialector,
+// 例如从环境变量读取 dsn, 未设置时跳过:
+//
+//	func TestFastcurdMySQL(t *testing.T) {
+//		dsn := os.Getenv("BDK_TEST_MYSQL_DSN")
+//		if dsn == "" {
+//			t.Skip("BDK_TEST_MYSQL_DSN not set")
+//		}
+//		db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		t.Cleanup(func() { _ = curdtest.DropTable(db) })
+//		for _, c := range curdtest.Cases(db) {
+//			if !t.Run(c.Name, func(t *testing.T) {
+//				if err := c.Run(); err != nil {
+//					t.Fatal(err)
+//				}
+//			}) {
+//				break
+//			}
+//		}
+//	}
+package curdtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/real-web-world/bdk/fastcurd"
+)
+
+const (
+	TableName = "bdk_curdtest_record"
+)
+
+type (
+	// Case 单个测试步骤 后续步骤依赖之前步骤写入的数据
+	Case struct {
+		Name string
+		Run  func() error
+	}
+	Record struct {
+		fastcurd.Base
+		Name  string `json:"name" gorm:"size:64;not null;default:''"`
+		Phone string `json:"phone" gorm:"size:32;not null;default:''"`
+		Score int    `json:"score" gorm:"not null;default:0"`
+	}
+)
+
+func (m *Record) TableName() string {
+	return TableName
+}
+func (m *Record) GetFilterKeyMapDBField() map[string]string {
+	return map[string]string{
+		fastcurd.PrimaryField: fastcurd.PrimaryField,
+		"name":                "name",
+		"score":               "score",
+	}
+}
+func (m *Record) GetOrderKeyMapDBField() map[string]string {
+	return map[string]string{
+		fastcurd.PrimaryField: fastcurd.PrimaryField,
+		"score":               "score",
+	}
+}
+func (m *Record) GetMaskRules() []fastcurd.MaskRule {
+	return []fastcurd.MaskRule{
+		{Field: "phone", Masker: fastcurd.MaskPhone},
+	}
+}
+func (m *Record) GetFmtDetail(...string) any {
+	return map[string]any{
+		"id":    m.ID,
+		"name":  m.Name,
+		"phone": m.Phone,
+		"score": m.Score,
+	}
+}
+
+// DropTable 删除测试表 在 Cases 执行结束后调用
+func DropTable(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Record{})
+}
+
+// Cases 在db上建表并依次测试 fastcurd 的增删改查 需按顺序执行
+func Cases(db *gorm.DB) []Case {
+	m := &Record{Base: fastcurd.Base{DB: db, Ctx: context.Background()}}
+	var idArr []int64
+	return []Case{
+		{Name: "AutoMigrate", Run: func() error {
+			_ = DropTable(db)
+			return db.AutoMigrate(&Record{})
+		}},
+		{Name: "CreateRecord", Run: func() error {
+			record := &Record{Name: "first", Phone: "13812345678", Score: 1}
+			res, err := fastcurd.CreateRecord(m, &record)
+			if err != nil {
+				return fmt.Errorf("create record: %w", err)
+			}
+			if (*res).ID == 0 {
+				return errors.New("create record: id not assigned")
+			}
+			idArr = append(idArr, (*res).ID)
+			return nil
+		}},
+		{Name: "CreateList", Run: func() error {
+			list := []*Record{
+				{Name: "second", Phone: "13812345679", Score: 2},
+				{Name: "third", Phone: "13812345670", Score: 3},
+			}
+			res, err := fastcurd.CreateList(m, list)
+			if err != nil {
+				return fmt.Errorf("create list: %w", err)
+			}
+			for _, item := range res {
+				if item.ID == 0 {
+					return errors.New("create list: id not assigned")
+				}
+				idArr = append(idArr, item.ID)
+			}
+			if len(idArr) != 3 {
+				return fmt.Errorf("create list: expect 3 records, got %d", len(idArr))
+			}
+			return nil
+		}},
+		{Name: "GetDetailByID", Run: func() error {
+			record, err := fastcurd.GetDetailByID(m, idArr[0])
+			if err != nil {
+				return fmt.Errorf("get detail: %w", err)
+			}
+			if record.Name != "first" {
+				return fmt.Errorf("get detail: expect name first, got %s", record.Name)
+			}
+			if record.Ctime == nil || record.Utime == nil {
+				return errors.New("get detail: ctime/utime default not applied")
+			}
+			return nil
+		}},
+		{Name: "ListByIDArr", Run: func() error {
+			list, err := fastcurd.ListByIDArr(m, idArr[:2])
+			if err != nil {
+				return fmt.Errorf("list by id arr: %w", err)
+			}
+			if len(list) != 2 {
+				return fmt.Errorf("list by id arr: expect 2, got %d", len(list))
+			}
+			return nil
+		}},
+		{Name: "ListRecord", Run: func() error {
+			filter := fastcurd.Filter{
+				"score": {Condition: fastcurd.CondEgt, Val: 2},
+			}
+			order := map[string]string{"score": fastcurd.OrderDesc}
+			list, count, err := fastcurd.ListRecord(m, 1, 1, filter, order)
+			if err != nil {
+				return fmt.Errorf("list record: %w", err)
+			}
+			if count != 2 || len(list) != 1 || list[0].Score != 3 {
+				return fmt.Errorf("list record: unexpected result count=%d len=%d", count, len(list))
+			}
+			return nil
+		}},
+		{Name: "EditByID", Run: func() error {
+			affect, err := fastcurd.EditByID(m, idArr[0], map[string]any{"score": 10})
+			if err != nil || affect != 1 {
+				return fmt.Errorf("edit by id: affect=%d err=%v", affect, err)
+			}
+			err = db.Transaction(func(tx *gorm.DB) error {
+				_, err := fastcurd.TxEditByID(m, tx, idArr[0], map[string]any{"name": "first-tx"})
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("tx edit by id: %w", err)
+			}
+			record, _ := fastcurd.GetDetailByID(m, idArr[0])
+			if record == nil || record.Score != 10 || record.Name != "first-tx" {
+				return errors.New("edit by id: values not saved")
+			}
+			return nil
+		}},
+		{Name: "EditByIDArr", Run: func() error {
+			affect, err := fastcurd.EditByIDArr(m, idArr[1:], map[string]any{"score": 20})
+			if err != nil || affect != 2 {
+				return fmt.Errorf("edit by id arr: affect=%d err=%v", affect, err)
+			}
+			return nil
+		}},
+		{Name: "GetFmtList", Run: func() error {
+			list, _ := fastcurd.ListByIDArr(m, idArr[:1])
+			fmtList := fastcurd.GetFmtList(list).([]any)
+			if len(fmtList) != 1 || fmtList[0].(map[string]any)["phone"] != "138****5678" {
+				return fmt.Errorf("get fmt list: phone not masked %v", fmtList)
+			}
+			return nil
+		}},
+		{Name: "DelByIDArr", Run: func() error {
+			affect, err := fastcurd.DelByIDArr(m, idArr[:1])
+			if err != nil || affect != 1 {
+				return fmt.Errorf("del by id arr: affect=%d err=%v", affect, err)
+			}
+			err = db.Transaction(func(tx *gorm.DB) error {
+				_, err := fastcurd.TxDelByIDArr(m, tx, idArr[1:])
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("tx del by id arr: %w", err)
+			}
+			var count int64
+			db.Model(&Record{}).Count(&count)
+			if count != 0 {
+				return fmt.Errorf("del by id arr: expect 0 records left, got %d", count)
+			}
+			return nil
+		}},
+	}
+}
@@ -1,12 +1,13 @@
 package fastcurd
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/real-web-world/bdk/i18n"
 )
 
 type (
@@ -76,7 +77,7 @@ func FmtCondVal(cond FilterCond, val any) (any, error) {
 	switch cond {
 	case CondLike, CondNotLike:
 		if val, ok := val.(string); !ok {
-			return nil, errors.New("筛选条件为" + string(cond) + "时,val必须为字符串")
+			return nil, i18n.NewError(i18n.MsgFastcurdCondValMustBeString, cond)
 		} else {
 			return "%" + val + "%", nil
 		}
@@ -93,7 +94,7 @@ func FmtCondVal(cond FilterCond, val any) (any, error) {
 		case []any:
 			return val, nil
 		default:
-			return nil, errors.New("筛选条件为" + string(cond) + "时,val必须为数组")
+			return nil, i18n.NewError(i18n.MsgFastcurdCondValMustBeArray, cond)
 		}
 	default:
 		return val, nil
@@ -0,0 +1,207 @@
+package fastcurd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/real-web-world/bdk/json"
+)
+
+// MaskedPlaceholder 无法按位脱敏的值(如对象 数组)整体替换为该值
+const MaskedPlaceholder = "***"
+
+type (
+	// Masker 脱敏函数 返回脱敏后的值
+	Masker   func(val any) any
+	MaskRule struct {
+		Field        string   // 场景输出中的字段 支持 a.b 形式的嵌套字段
+		Masker       Masker   // 脱敏函数
+		Scenes       []string // 生效的场景 为空时所有场景生效
+		VisibleRoles []string // 拥有其中任一角色的调用方可查看原值
+	}
+	// MaskRuleProvider 模型实现该接口以在场景输出中脱敏
+	MaskRuleProvider interface {
+		GetMaskRules() []MaskRule
+	}
+)
+
+// MaskPhone 手机号保留前3位和后4位 13812345678 -> 138****5678
+func MaskPhone(val any) any {
+	return MaskMiddle(3, 4)(val)
+}
+
+// MaskEmail 邮箱保留本地部分首字符 foo@bar.com -> f***@bar.com
+func MaskEmail(val any) any {
+	str, ok := maskString(val)
+	if !ok {
+		return MaskedPlaceholder
+	}
+	if str == "" {
+		return val
+	}
+	at := strings.LastIndex(str, "@")
+	if at <= 0 {
+		return MaskMiddle(1, 0)(str)
+	}
+	local := []rune(str[:at])
+	return string(local[0]) + "***" + str[at:]
+}
+
+// MaskIDCard 身份证号保留前6位和后4位
+func MaskIDCard(val any) any {
+	return MaskMiddle(6, 4)(val)
+}
+
+// MaskMiddle 保留前keepHead位和后keepTail位 中间以*替换 数字按十进制字符串处理
+func MaskMiddle(keepHead, keepTail int) Masker {
+	return func(val any) any {
+		str, ok := maskString(val)
+		if !ok {
+			return MaskedPlaceholder
+		}
+		if str == "" {
+			return val
+		}
+		runes := []rune(str)
+		if len(runes) <= keepHead+keepTail {
+			return strings.Repeat("*", len(runes))
+		}
+		return string(runes[:keepHead]) + strings.Repeat("*", len(runes)-keepHead-keepTail) +
+			string(runes[len(runes)-keepTail:])
+	}
+}
+
+// MaskFixed 直接替换为固定值
+func MaskFixed(replacement any) Masker {
+	return func(val any) any {
+		if val == nil {
+			return nil
+		}
+		return replacement
+	}
+}
+
+// ApplyMask 按规则对场景输出进行脱敏 detail非map时会先转为map
+func ApplyMask(ctx context.Context, scene string, detail any, rules []MaskRule) any {
+	if len(rules) == 0 || detail == nil {
+		return detail
+	}
+	var caller Caller
+	if ctx != nil {
+		caller = GetCaller(ctx)
+	}
+	data, ok := detail.(map[string]any)
+	for _, rule := range rules {
+		if rule.Masker == nil || (len(rule.Scenes) > 0 && !slices.Contains(rule.Scenes, scene)) ||
+			HasCallerRole(caller, rule.VisibleRoles...) {
+			continue
+		}
+		if !ok {
+			if data, ok = toMaskMap(detail); !ok {
+				return detail
+			}
+		}
+		maskField(data, strings.Split(rule.Field, "."), rule.Masker)
+	}
+	if data == nil {
+		return detail
+	}
+	return data
+}
+
+// GetMaskFmtDetail 获取脱敏后的场景输出 调用方取自m的ctx
+func GetMaskFmtDetail[P BaseModel[M], M any](m P, sceneParam ...string) any {
+	return GetCtxMaskFmtDetail(m.GetCtx(), m, sceneParam...)
+}
+func GetCtxMaskFmtDetail[P BaseModel[M], M any](ctx context.Context, m P, sceneParam ...string) any {
+	scene := ""
+	if len(sceneParam) > 0 {
+		scene = sceneParam[0]
+	}
+	detail := m.GetFmtDetail(scene)
+	if provider, ok := any(m).(MaskRuleProvider); ok {
+		return ApplyMask(ctx, scene, detail, provider.GetMaskRules())
+	}
+	return detail
+}
+func GetCtxFmtList[P BaseModel[M], M any](ctx context.Context, arr []P, sceneParam ...string) any {
+	fmtList := make([]any, 0, len(arr))
+	for _, item := range arr {
+		fmtList = append(fmtList, GetCtxMaskFmtDetail(ctx, item, sceneParam...))
+	}
+	return fmtList
+}
+
+// maskString 标量转为字符串 json.Number 等 Stringer 取 String() 对象 数组等返回false
+func maskString(val any) (string, bool) {
+	switch v := val.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case fmt.Stringer:
+		return v.String(), true
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Bool:
+		return fmt.Sprint(val), true
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return "", true
+		}
+		return maskString(rv.Elem().Interface())
+	}
+	return "", false
+}
+
+// toMaskMap 数字保留为 json.Number 避免超过2^53的id经float64丢失精度
+func toMaskMap(detail any) (map[string]any, bool) {
+	bts, err := json.Marshal(detail)
+	if err != nil {
+		return nil, false
+	}
+	data := make(map[string]any)
+	dec := json.NewDecoder(bytes.NewReader(bts))
+	dec.UseNumber()
+	if err = dec.Decode(&data); err != nil {
+		return nil, false
+	}
+	return data, true
+}
+func maskField(data map[string]any, path []string, masker Masker) {
+	val, ok := data[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		data[path[0]] = masker(val)
+		return
+	}
+	switch child := val.(type) {
+	case map[string]any:
+		maskField(child, path[1:], masker)
+	case []any:
+		for _, item := range child {
+			if itemMap, ok := item.(map[string]any); ok {
+				maskField(itemMap, path[1:], masker)
+			}
+		}
+	case []map[string]any:
+		for _, item := range child {
+			maskField(item, path[1:], masker)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package fastcurd
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type testCaller struct {
+	id    string
+	roles []string
+}
+
+func (c testCaller) GetCallerID() string {
+	return c.id
+}
+func (c testCaller) GetCallerRoles() []string {
+	return c.roles
+}
+
+type phoneNum string
+
+func TestMaskPhone(t *testing.T) {
+	phoneInt := int64(13812345678)
+	cases := []struct {
+		name string
+		val  any
+		want any
+	}{
+		{name: "string", val: "13812345678", want: "138****5678"},
+		{name: "empty", val: "", want: ""},
+		{name: "nil", val: nil, want: nil},
+		{name: "short", val: "123", want: "***"},
+		{name: "int64", val: phoneInt, want: "138****5678"},
+		{name: "int pointer", val: &phoneInt, want: "138****5678"},
+		{name: "float64 from json", val: float64(13812345678), want: "138****5678"},
+		{name: "json number", val: json.Number("13812345678"), want: "138****5678"},
+		{name: "named string", val: phoneNum("13812345678"), want: "138****5678"},
+		{name: "unicode", val: "张三丰和张无忌八", want: "张三丰*张无忌八"},
+		{name: "object", val: map[string]any{"a": 1}, want: MaskedPlaceholder},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := MaskPhone(tc.val); got != tc.want {
+				t.Fatalf("MaskPhone(%v) = %v, want %v", tc.val, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaskEmail(t *testing.T) {
+	cases := []struct {
+		val  any
+		want any
+	}{
+		{val: "foo@bar.com", want: "f***@bar.com"},
+		{val: "noat", want: "n***"},
+		{val: "", want: ""},
+		{val: []string{"a"}, want: MaskedPlaceholder},
+	}
+	for _, tc := range cases {
+		if got := MaskEmail(tc.val); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("MaskEmail(%v) = %v, want %v", tc.val, got, tc.want)
+		}
+	}
+}
+
+func TestApplyMask(t *testing.T) {
+	rules := []MaskRule{
+		{Field: "phone", Masker: MaskPhone, VisibleRoles: []string{"admin"}},
+		{Field: "user.idCard", Masker: MaskIDCard},
+		{Field: "list.phone", Masker: MaskPhone, Scenes: []string{"list"}},
+	}
+	newDetail := func() map[string]any {
+		return map[string]any{
+			"phone": "13812345678",
+			"user":  map[string]any{"idCard": "110101199001011234"},
+			"list":  []any{map[string]any{"phone": "13812345678"}},
+		}
+	}
+	admin := WithCaller(context.Background(), testCaller{id: "1", roles: []string{"admin"}})
+	cases := []struct {
+		name      string
+		ctx       context.Context
+		scene     string
+		wantPhone any
+		wantList  any
+	}{
+		{name: "no caller", ctx: nil, wantPhone: "138****5678", wantList: "13812345678"},
+		{name: "visible role", ctx: admin, wantPhone: "13812345678", wantList: "13812345678"},
+		{name: "scene rule", ctx: admin, scene: "list", wantPhone: "13812345678", wantList: "138****5678"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := ApplyMask(tc.ctx, tc.scene, newDetail(), rules).(map[string]any)
+			if data["phone"] != tc.wantPhone {
+				t.Fatalf("phone = %v, want %v", data["phone"], tc.wantPhone)
+			}
+			if got := data["user"].(map[string]any)["idCard"]; got != "110101********1234" {
+				t.Fatalf("idCard = %v", got)
+			}
+			if got := data["list"].([]any)[0].(map[string]any)["phone"]; got != tc.wantList {
+				t.Fatalf("list phone = %v, want %v", got, tc.wantList)
+			}
+		})
+	}
+}
+
+func TestApplyMaskStructDetail(t *testing.T) {
+	type detail struct {
+		ID    int64  `json:"id"`
+		Phone string `json:"phone"`
+	}
+	const bigID = int64(1)<<53 + 1
+	rules := []MaskRule{{Field: "phone", Masker: MaskPhone}}
+	cases := []struct {
+		name   string
+		detail any
+		want   string
+	}{
+		{name: "int64 above 2^53", detail: detail{ID: bigID, Phone: "13812345678"}, want: `{"id":9007199254740993,"phone":"138****5678"}`},
+		{name: "pointer", detail: &detail{ID: 1, Phone: "13812345678"}, want: `{"id":1,"phone":"138****5678"}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bts, err := json.Marshal(ApplyMask(nil, "", tc.detail, rules))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bts) != tc.want {
+				t.Fatalf("ApplyMask() = %s, want %s", bts, tc.want)
+			}
+		})
+	}
+}
@@ -38,14 +38,18 @@ type (
 		GetFilterKeyMapDBField() map[string]string
 		GetOrderKeyMapDBField() map[string]string
 	}
+	// ctxSetter 查询结果由 new(M) 创建 通过该接口继承查询时的ctx 以便脱敏与策略取到调用方
+	ctxSetter interface {
+		SetCtx(ctx context.Context)
+	}
 	BaseModel[P any] interface {
 		constraints.Ptr[P]
 		BaseModelBase[*P]
 	}
 	Base struct {
-		ID                 int64           `json:"id" redis:"id" gorm:"type:bitint unsigned auto_increment;primaryKey;"`
-		Ctime              *time.Time      `json:"ctime,omitempty" gorm:"type:timestamptz;default:current_timestamp;not null;"`
-		Utime              *time.Time      `json:"utime,omitempty" gorm:"type:timestamptz;default:current_timestamp;not null;"`
+		ID                 int64           `json:"id" redis:"id" gorm:"primaryKey;autoIncrement;"`
+		Ctime              *time.Time      `json:"ctime,omitempty" gorm:"default:current_timestamp;not null;"`
+		Utime              *time.Time      `json:"utime,omitempty" gorm:"default:current_timestamp;not null;"`
 		RelationAffectRows int             `json:"-" gorm:"-"` // 更新时用来保存其他关联数据的更新数
 		Ctx                context.Context `json:"-" gorm:"-"` // ctx
 		DB                 *gorm.DB        `json:"-" gorm:"-"` // db
@@ -58,6 +62,9 @@ func (m *Base) GetDB() *gorm.DB {
 func (m *Base) GetCtx() context.Context {
 	return m.Ctx
 }
+func (m *Base) SetCtx(ctx context.Context) {
+	m.Ctx = ctx
+}
 func (m *Base) GetFilterKeyMapDBField() map[string]string {
 	return defaultFilterKeyMapDbField
 }
@@ -106,6 +113,8 @@ func GetTxGormQuery[P BaseModel[M], M any](m P, tx *gorm.DB) *gorm.DB {
 	return db.Model(m)
 }
 
+// GetFmtList 按各记录的ctx脱敏 查询函数返回的记录已继承查询时的ctx
+// 其他来源的记录应使用 GetCtxFmtList 传入调用方的ctx
 func GetFmtList[P BaseModel[M], M any](arr []P, sceneParam ...string) any {
 	scene := ""
 	if len(sceneParam) > 0 {
@@ -114,51 +123,62 @@ func GetFmtList[P BaseModel[M], M any](arr []P, sceneParam ...string) any {
 	fmtList := make([]any, 0, len(arr))
 	actList := arr
 	for _, item := range actList {
-		fmtList = append(fmtList, item.GetFmtDetail(scene))
+		fmtList = append(fmtList, GetMaskFmtDetail(item, scene))
 	}
 	return fmtList
 }
 func GetDetailByID[P BaseModel[M], M any](m P, id int64) (P, error) {
 	record := new(M)
-	err := GetGormQuery(m).Where("id = ?", id).First(record).Error
+	err := applyReadPolicy(m, GetGormQuery(m)).Where("id = ?", id).First(record).Error
 	if err != nil {
-		record = nil
+		return nil, err
 	}
-	return record, err
+	inheritCtx(m, record)
+	return record, nil
 }
 func ListByIDArr[P BaseModel[M], M any](m P, idArr []int64) ([]P, error) {
 	if len(idArr) == 0 {
 		return nil, nil
 	}
 	list := make([]P, 0, len(idArr))
-	err := GetGormQuery(m).Where("id in ?", idArr).Find(&list).Error
+	err := applyReadPolicy(m, GetGormQuery(m)).Where("id in ?", idArr).Find(&list).Error
+	inheritCtx(m, list...)
 	return list, err
 }
-func dbEditByID(db *gorm.DB, id int64, values map[string]any) (int64, error) {
-	res := db.Where("id = ?", id).Updates(values)
+func dbEditByID[P BaseModel[M], M any](m P, db *gorm.DB, id int64, values map[string]any) (int64, error) {
+	if err := checkEditPolicy(m, []int64{id}); err != nil {
+		return 0, err
+	}
+	res := applyReadPolicy(m, db).Where("id = ?", id).Updates(values)
 	return res.RowsAffected, res.Error
 }
 func EditByID[P BaseModel[M], M any](m P, id int64, values map[string]any) (int64, error) {
-	return dbEditByID(GetGormQuery(m), id, values)
+	return dbEditByID(m, GetGormQuery(m), id, values)
 }
 func TxEditByID[P BaseModel[M], M any](m P, tx *gorm.DB, id int64, values map[string]any) (int64, error) {
-	return dbEditByID(GetTxGormQuery(m, tx), id, values)
+	return dbEditByID(m, GetTxGormQuery(m, tx), id, values)
 }
-func dbEditByIDArr(db *gorm.DB, idArr []int64, values map[string]any) (int64, error) {
-	res := db.Where("id in ?", idArr).Updates(values)
+func dbEditByIDArr[P BaseModel[M], M any](m P, db *gorm.DB, idArr []int64, values map[string]any) (int64, error) {
+	if err := checkEditPolicy(m, idArr); err != nil {
+		return 0, err
+	}
+	res := applyReadPolicy(m, db).Where("id in ?", idArr).Updates(values)
 	return res.RowsAffected, res.Error
 }
 func EditByIDArr[P BaseModel[M], M any](m P, idArr []int64, values map[string]any) (int64, error) {
-	return dbEditByIDArr(GetGormQuery(m), idArr, values)
+	return dbEditByIDArr(m, GetGormQuery(m), idArr, values)
 }
 func TxEditByIDArr[P BaseModel[M], M any](m P, tx *gorm.DB, idArr []int64, values map[string]any) (int64, error) {
-	return dbEditByIDArr(GetTxGormQuery(m, tx), idArr, values)
+	return dbEditByIDArr(m, GetTxGormQuery(m, tx), idArr, values)
 }
 func dbDelByIDArr[P BaseModel[M], M any](m P, db *gorm.DB, idArr []int64) (int64, error) {
 	if len(idArr) == 0 {
 		return 0, nil
 	}
-	res := db.Where("id in ?", idArr).Delete(m)
+	if err := checkDeletePolicy(m, idArr); err != nil {
+		return 0, err
+	}
+	res := applyReadPolicy(m, db).Where("id in ?", idArr).Delete(m)
 	return res.RowsAffected, res.Error
 }
 func DelByIDArr[P BaseModel[M], M any](m P, idArr []int64) (int64, error) {
@@ -203,7 +223,7 @@ func ListRecord[P BaseModel[M], M any](m P, page, limit int, filter Filter, orde
 		offset = (page - 1) * limit
 	}
 	list := make([]P, 0, limit)
-	db := GetGormQuery(m)
+	db := applyReadPolicy(m, GetGormQuery(m))
 	query, err := BuildFilterCond(m.GetFilterKeyMapDBField(), db, filter)
 	if err != nil {
 		return nil, count, err
@@ -217,5 +237,20 @@ func ListRecord[P BaseModel[M], M any](m P, page, limit int, filter Filter, orde
 		dataQuery = BuildOrderCond(m.GetOrderKeyMapDBField(), dataQuery, order)
 		return dataQuery.Offset(offset).Limit(limit).Find(&list).Error
 	})
-	return list, count, g.Wait()
+	err = g.Wait()
+	inheritCtx(m, list...)
+	return list, count, err
+}
+
+// inheritCtx 查询结果继承m的ctx
+func inheritCtx[P BaseModel[M], M any](m P, list ...P) {
+	ctx := m.GetCtx()
+	if ctx == nil {
+		return
+	}
+	for _, item := range list {
+		if setter, ok := any(item).(ctxSetter); ok && item.GetCtx() == nil {
+			setter.SetCtx(ctx)
+		}
+	}
 }
@@ -0,0 +1,104 @@
+package fastcurd
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPolicyDenied = errors.New("policy denied")
+)
+
+type (
+	callerCtxKey struct{}
+	// Caller 当前请求的调用方身份
+	Caller interface {
+		GetCallerID() string
+		GetCallerRoles() []string
+	}
+	// Policy 行级权限策略
+	// ReadScope 为读取(及编辑/删除的范围)追加额外的过滤条件, 返回nil表示不限制
+	// AllowEdit/AllowDelete 决定调用方能否编辑/删除给定id的记录
+	Policy interface {
+		ReadScope(ctx context.Context, caller Caller) func(db *gorm.DB) *gorm.DB
+		AllowEdit(ctx context.Context, caller Caller, idArr []int64) bool
+		AllowDelete(ctx context.Context, caller Caller, idArr []int64) bool
+	}
+	// PolicyProvider 模型实现该接口以启用行级权限策略
+	PolicyProvider interface {
+		GetPolicy() Policy
+	}
+)
+
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerCtxKey{}, caller)
+}
+func GetCaller(ctx context.Context) Caller {
+	if ctx == nil {
+		return nil
+	}
+	caller, _ := ctx.Value(callerCtxKey{}).(Caller)
+	return caller
+}
+func HasCallerRole(caller Caller, roles ...string) bool {
+	if caller == nil {
+		return false
+	}
+	for _, role := range caller.GetCallerRoles() {
+		for _, r := range roles {
+			if role == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func getModelCtx[P BaseModel[M], M any](m P) context.Context {
+	ctx := m.GetCtx()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return ctx
+}
+func getPolicy(m any) Policy {
+	if provider, ok := m.(PolicyProvider); ok {
+		return provider.GetPolicy()
+	}
+	return nil
+}
+func applyReadPolicy[P BaseModel[M], M any](m P, db *gorm.DB) *gorm.DB {
+	policy := getPolicy(m)
+	if policy == nil {
+		return db
+	}
+	ctx := getModelCtx(m)
+	if scope := policy.ReadScope(ctx, GetCaller(ctx)); scope != nil {
+		db = db.Scopes(scope)
+	}
+	return db
+}
+func checkEditPolicy[P BaseModel[M], M any](m P, idArr []int64) error {
+	policy := getPolicy(m)
+	if policy == nil {
+		return nil
+	}
+	ctx := getModelCtx(m)
+	if !policy.AllowEdit(ctx, GetCaller(ctx), idArr) {
+		return ErrPolicyDenied
+	}
+	return nil
+}
+func checkDeletePolicy[P BaseModel[M], M any](m P, idArr []int64) error {
+	policy := getPolicy(m)
+	if policy == nil {
+		return nil
+	}
+	ctx := getModelCtx(m)
+	if !policy.AllowDelete(ctx, GetCaller(ctx), idArr) {
+		return ErrPolicyDenied
+	}
+	return nil
+}
@@ -5,8 +5,8 @@ import (
 )
 
 type RespJsonExtra struct {
-	//ReqID    string             `json:"reqID"`
-	ProcTime time.Duration `json:"procTime" example:"0.2s"`
+	ReqID    string        `json:"reqID,omitempty"`
+	ProcTime time.Duration `json:"procTime,omitempty" example:"0.2s"`
 	//TempData any    `json:"tempData,omitempty"`
 }
 
@@ -14,11 +14,11 @@ type RespJsonExtra struct {
 // 所有的接口均返回此对象
 
 type RetJSON struct {
-	Code  Code   `json:"code" example:"0"`
-	Data  any    `json:"data,omitempty"`
-	Msg   string `json:"msg,omitempty" example:"提示信息"`
-	Page  int    `json:"page,omitempty"`
-	Limit int    `json:"limit,omitempty"`
-	Count int64  `json:"count,omitempty"`
-	//Extra RespJsonExtra `json:"extra,omitempty"`
+	Code  Code           `json:"code" example:"0"`
+	Data  any            `json:"data,omitempty"`
+	Msg   string         `json:"msg,omitempty" example:"提示信息"`
+	Page  int            `json:"page,omitempty"`
+	Limit int            `json:"limit,omitempty"`
+	Count int64          `json:"count,omitempty"`
+	Extra *RespJsonExtra `json:"extra,omitempty"`
 }
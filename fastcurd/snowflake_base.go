@@ -0,0 +1,94 @@
+package fastcurd
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/real-web-world/bdk/json"
+	"github.com/real-web-world/bdk/snowflake"
+)
+
+var (
+	idGenerator IDGenerator
+)
+
+type (
+	IDGenerator interface {
+		NextID() (int64, error)
+	}
+	// defaultIDGenerator 每次调用时取 snowflake 默认生成器 无法确定节点id时返回错误
+	defaultIDGenerator struct{}
+	// SnowflakeBase 与Base相同 但id在创建前由IDGenerator分配 而非数据库自增
+	// id超过js的安全整数范围 json中序列化为字符串
+	SnowflakeBase struct {
+		ID                 int64           `json:"id,string" redis:"id" gorm:"primaryKey;autoIncrement:false;"`
+		Ctime              *time.Time      `json:"ctime,omitempty" gorm:"default:current_timestamp;not null;"`
+		Utime              *time.Time      `json:"utime,omitempty" gorm:"default:current_timestamp;not null;"`
+		RelationAffectRows int             `json:"-" gorm:"-"` // 更新时用来保存其他关联数据的更新数
+		Ctx                context.Context `json:"-" gorm:"-"` // ctx
+		DB                 *gorm.DB        `json:"-" gorm:"-"` // db
+	}
+)
+
+// SetIDGenerator 设置SnowflakeBase使用的id生成器 默认使用 snowflake.Default()
+func SetIDGenerator(g IDGenerator) {
+	idGenerator = g
+}
+func GetIDGenerator() IDGenerator {
+	if idGenerator == nil {
+		return defaultIDGenerator{}
+	}
+	return idGenerator
+}
+func (defaultIDGenerator) NextID() (int64, error) {
+	return snowflake.NextID()
+}
+
+// BeforeCreate CreateRecord/CreateList 写入前分配id 生成失败时返回错误 gorm中止创建
+func (m *SnowflakeBase) BeforeCreate(*gorm.DB) error {
+	if m.ID != 0 {
+		return nil
+	}
+	id, err := GetIDGenerator().NextID()
+	if err != nil {
+		return err
+	}
+	m.ID = id
+	return nil
+}
+
+// base 两者字段相同 仅标签不同 除id的序列化外复用Base的方法
+func (m *SnowflakeBase) base() *Base {
+	return (*Base)(m)
+}
+func (m *SnowflakeBase) GetDB() *gorm.DB {
+	return m.base().GetDB()
+}
+func (m *SnowflakeBase) GetCtx() context.Context {
+	return m.base().GetCtx()
+}
+func (m *SnowflakeBase) SetCtx(ctx context.Context) {
+	m.base().SetCtx(ctx)
+}
+func (m *SnowflakeBase) GetFilterKeyMapDBField() map[string]string {
+	return m.base().GetFilterKeyMapDBField()
+}
+func (m *SnowflakeBase) GetOrderKeyMapDBField() map[string]string {
+	return m.base().GetOrderKeyMapDBField()
+}
+func (m *SnowflakeBase) GetFmtDetail(scenes ...string) any {
+	model := m.base().GetFmtDetail(scenes...)
+	if detail, ok := model.(map[string]any); ok {
+		detail["id"] = strconv.FormatInt(m.ID, 10)
+	}
+	return model
+}
+func (m *SnowflakeBase) MarshalBinary() (data []byte, err error) {
+	return json.Marshal(m)
+}
+func (m *SnowflakeBase) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, m)
+}
@@ -0,0 +1,52 @@
+package fastcurd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/real-web-world/bdk/json"
+)
+
+func TestSnowflakeBaseJSON(t *testing.T) {
+	const id = int64(1)<<53 + 1
+	m := &SnowflakeBase{ID: id}
+	bts, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bts), `"id":"9007199254740993"`) {
+		t.Fatalf("id not encoded as string: %s", bts)
+	}
+	got := &SnowflakeBase{}
+	if err := json.Unmarshal(bts, got); err != nil || got.ID != id {
+		t.Fatalf("round trip id = %d, err = %v", got.ID, err)
+	}
+	detail, ok := m.GetFmtDetail().(map[string]any)
+	if !ok || detail["id"] != "9007199254740993" {
+		t.Fatalf("fmt detail id = %#v", detail["id"])
+	}
+}
+
+type fixedGen int64
+
+func (g fixedGen) NextID() (int64, error) {
+	return int64(g), nil
+}
+
+func TestSnowflakeBaseBeforeCreate(t *testing.T) {
+	SetIDGenerator(fixedGen(99))
+	defer SetIDGenerator(nil)
+	cases := []struct {
+		id   int64
+		want int64
+	}{
+		{id: 0, want: 99},
+		{id: 5, want: 5},
+	}
+	for _, tc := range cases {
+		m := &SnowflakeBase{ID: tc.id}
+		if err := m.BeforeCreate(nil); err != nil || m.ID != tc.want {
+			t.Errorf("id %d: got %d, err %v", tc.id, m.ID, err)
+		}
+	}
+}
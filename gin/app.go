@@ -3,28 +3,43 @@ package ginApp
 import (
 	"errors"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 
+	"github.com/real-web-world/bdk"
+	"github.com/real-web-world/bdk/errs"
 	"github.com/real-web-world/bdk/fastcurd"
-	"github.com/real-web-world/bdk/json"
+	"github.com/real-web-world/bdk/i18n"
+	"github.com/real-web-world/bdk/valid"
 )
 
 const (
-	HeaderReqID       = "X-Request-ID"
+	HeaderReqID       = bdk.HeadReqID
+	HeaderAccept      = "Accept"
+	HeaderVary        = "Vary"
 	HeaderContentType = "Content-Type"
 	ContentTypeJSON   = "application/json; charset=utf-8"
 )
 
+const (
+	KeyReqID              = "bdk.reqID"
+	HeaderAcceptLanguage  = "Accept-Language"
+	HeaderContentLanguage = "Content-Language"
+	QueryLocale           = "lang"
+	KeyLocale             = "bdk.locale"
+)
+
+// Msg 为消息id 响应时按调用方语言翻译
 var (
-	respServerBad    = fastcurd.RetJSON{Code: fastcurd.CodeServerError, Msg: "服务器开小差了~"}
-	respBadReq       = fastcurd.RetJSON{Code: fastcurd.CodeBadReq, Msg: "错误的请求"}
-	respNoChange     = fastcurd.RetJSON{Code: fastcurd.CodeDefaultError, Msg: "无更新"}
-	respNoAuth       = fastcurd.RetJSON{Code: fastcurd.CodeNoAuth, Msg: "未授权"}
-	respNoLogin      = fastcurd.RetJSON{Code: fastcurd.CodeNoLogin, Msg: "未登录"}
-	respReqFrequency = fastcurd.RetJSON{Code: fastcurd.CodeRateLimitError, Msg: "请求速度太快了~"}
+	respServerBad    = fastcurd.RetJSON{Code: fastcurd.CodeServerError, Msg: i18n.MsgServerBad}
+	respBadReq       = fastcurd.RetJSON{Code: fastcurd.CodeBadReq, Msg: i18n.MsgBadReq}
+	respNoChange     = fastcurd.RetJSON{Code: fastcurd.CodeNoChange, Msg: i18n.MsgNoChange}
+	respNoAuth       = fastcurd.RetJSON{Code: fastcurd.CodeNoAuth, Msg: i18n.MsgNoAuth}
+	respNoLogin      = fastcurd.RetJSON{Code: fastcurd.CodeNoLogin, Msg: i18n.MsgNoLogin}
+	respReqFrequency = fastcurd.RetJSON{Code: fastcurd.CodeRateLimitError, Msg: i18n.MsgReqFrequency}
 	respSuccess      = fastcurd.RetJSON{Code: fastcurd.CodeOk}
 )
 
@@ -44,19 +59,47 @@ func NewGin() *gin.Engine {
 
 func GetApp(c *gin.Context) App {
 	return App{
-		C: c,
+		C:         c,
+		beginTime: c.GetTime(KeyProcBeginTime),
+		endTime:   c.GetTime(KeyProcEndTime),
 	}
 }
 
-// Response 最终响应
+// Response 最终响应 code会经过 ResponsePolicy 调整
 func (app App) Response(code int, retJson fastcurd.RetJSON) {
+	app.write(app.GetResponsePolicy().HTTPStatus(retJson.Code, code), retJson)
+}
+
+// write 响应管道: 执行响应钩子 -> 保存响应到上下文 -> 设置响应头 -> 写入状态码与响应体
+func (app App) write(code int, retJson fastcurd.RetJSON) {
+	app.endTime = SetProcEndTime(app.C)
+	for _, hook := range app.getRespHooks() {
+		hook(app, code, &retJson)
+	}
+	SetCtxRespVal(app.C, &retJson)
+	problem := app.shouldWriteProblem(retJson)
+	if !problem || app.GetErrorFormat() == ErrorFormatNegotiate {
+		// 响应格式按 Accept 协商 缓存需按 Accept 区分
+		app.addVary(HeaderAccept)
+	}
+	if problem {
+		app.writeProblem(code, retJson)
+		return
+	}
+	enc := NegotiateEncoder(app.C.GetHeader(HeaderAccept))
+	app.C.Writer.Header().Set(HeaderContentType, enc.ContentType())
 	app.C.Writer.WriteHeader(code)
-	app.C.Writer.Header().Set(HeaderContentType, ContentTypeJSON)
-	if err := json.NewEncoder(app.C.Writer).Encode(retJson); err != nil {
+	if err := enc.Encode(app.C.Writer, retJson); err != nil {
 		_ = app.C.Error(err)
 	}
 	app.C.Abort()
 }
+func (app App) addVary(header string) {
+	h := app.C.Writer.Header()
+	if !slices.Contains(h.Values(HeaderVary), header) {
+		h.Add(HeaderVary, header)
+	}
+}
 
 // resp helper
 
@@ -70,11 +113,33 @@ func (app App) Ok(msg string, params ...any) {
 func (app App) Data(data any) {
 	app.RetData(data)
 }
+
+// ServerError 内部错误 原因只记录到gin上下文 不返回给调用方
 func (app App) ServerError(err error) {
-	app.Response(http.StatusOK, fastcurd.RetJSON{Code: fastcurd.CodeServerError, Msg: err.Error()})
+	app.Error(errs.Internal(err))
+}
+
+// Error 统一错误响应 非*errs.Error视为服务器内部错误
+// 错误原因与Meta通过 c.Error 记录 由 middleware.NewErrorLog 输出 只有 Expose 的原因才会作为消息返回
+// WithStatus 指定的状态码在 LegacyResponsePolicy 下被忽略
+func (app App) Error(err error) {
+	bizErr := errs.As(err)
+	if bizErr.Cause != nil {
+		_ = app.C.Error(bizErr).SetType(gin.ErrorTypePrivate).SetMeta(bizErr.Meta)
+	}
+	resp := fastcurd.RetJSON{
+		Code: bizErr.Code,
+		Msg:  bizErr.PublicMsg(app.GetLocale()),
+		Data: bizErr.Data,
+	}
+	if bizErr.Status != 0 && !isLegacyResponsePolicy(app.GetResponsePolicy()) {
+		app.write(bizErr.Status, resp)
+		return
+	}
+	app.Response(legacyHTTPStatus(bizErr.Code), resp)
 }
 func (app App) ServerBad() {
-	app.Response(http.StatusOK, respServerBad)
+	app.Response(http.StatusOK, app.translateResp(respServerBad))
 }
 func (app App) RetData(data any, msgParam ...string) {
 	msg := ""
@@ -94,47 +159,64 @@ func (app App) SendList(list any, count int64) {
 	})
 }
 func (app App) BadReq() {
-	app.Response(http.StatusOK, respBadReq)
+	app.Response(http.StatusOK, app.translateResp(respBadReq))
 }
 func (app App) String(html string) {
 	app.C.String(http.StatusOK, html)
 }
+
+// ValidError 绑定或校验失败 请求体超过 BodyLimit 时与 App.Error 相同响应413
 func (app App) ValidError(err error) {
+	if errs.IsBodyTooLarge(err) {
+		app.Error(err)
+		return
+	}
 	resp := fastcurd.RetJSON{}
 	var actErr validator.ValidationErrors
 	switch {
 	case errors.As(err, &actErr):
+		fieldErrs := valid.FmtFieldErrors(actErr, i18n.ValidatorTranslator(app.GetLocale()))
 		resp.Code = fastcurd.CodeValidError
-		resp.Msg = actErr[0].Error()
+		resp.Msg = fieldErrs[0].Msg
+		resp.Data = fieldErrs
 	default:
 		if err.Error() == "EOF" {
 			resp.Code = fastcurd.CodeValidError
-			resp.Msg = "请求参数必填"
+			resp.Msg = app.T(i18n.MsgParamRequired)
 		} else {
 			resp.Code = fastcurd.CodeDefaultError
-			resp.Msg = actErr.Error()
+			resp.Msg = i18n.TranslateErr(app.GetLocale(), err)
 		}
 	}
 	app.Response(http.StatusOK, resp)
 }
 func (app App) NoChange() {
-	app.JSON(respNoChange)
+	app.JSON(app.translateResp(respNoChange))
 }
 func (app App) NoAuth() {
-	app.Response(http.StatusUnauthorized, respNoAuth)
+	app.Response(http.StatusUnauthorized, app.translateResp(respNoAuth))
 }
 func (app App) NoLogin() {
-	app.Response(http.StatusUnauthorized, respNoLogin)
+	app.Response(http.StatusUnauthorized, app.translateResp(respNoLogin))
 }
 func (app App) ErrorMsg(msg string) {
 	resp := fastcurd.RetJSON{Code: fastcurd.CodeDefaultError, Msg: msg}
 	app.Response(http.StatusOK, resp)
 }
 func (app App) CommonError(err error) {
-	app.ErrorMsg(err.Error())
+	var bizErr *errs.Error
+	if errors.As(err, &bizErr) {
+		app.Error(bizErr)
+		return
+	}
+	if errs.IsBodyTooLarge(err) {
+		app.Error(err)
+		return
+	}
+	app.ErrorMsg(i18n.TranslateErr(app.GetLocale(), err))
 }
 func (app App) RateLimitError() {
-	app.Response(http.StatusOK, respReqFrequency)
+	app.Response(http.StatusOK, app.translateResp(respReqFrequency))
 }
 func (app App) Success() {
 	app.Response(http.StatusOK, respSuccess)
@@ -150,10 +232,62 @@ func (app App) SendAffectRows(affectRows int) {
 func (app App) GetProcBeginTime() time.Time {
 	return app.beginTime
 }
+
+// GetReqID 优先取 RequestID 中间件生成的请求id
 func (app App) GetReqID() string {
+	if reqID := app.C.GetString(KeyReqID); reqID != "" {
+		return reqID
+	}
 	reqID := app.C.GetHeader(HeaderReqID)
 	return reqID
 }
+
+// GetProcTime 处理耗时 未经过 Timing 中间件时为0 尚未响应时计算到当前时间
 func (app App) GetProcTime() time.Duration {
-	return app.endTime.Sub(app.beginTime)
+	if app.beginTime.IsZero() {
+		return 0
+	}
+	endTime := app.endTime
+	if endTime.IsZero() {
+		endTime = app.C.GetTime(KeyProcEndTime)
+	}
+	if endTime.IsZero() {
+		return time.Since(app.beginTime)
+	}
+	return endTime.Sub(app.beginTime)
+}
+
+// legacyHTTPStatus 与 NoAuth/NoLogin 保持一致 限流为429(客户端需配合 Retry-After) 其余业务错误均为200
+func legacyHTTPStatus(code fastcurd.Code) int {
+	switch code {
+	case fastcurd.CodeNoAuth, fastcurd.CodeNoLogin:
+		return http.StatusUnauthorized
+	case fastcurd.CodeRateLimitError:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusOK
+	}
+}
+
+// i18n helper
+
+// GetLocale 调用方语言 依次取自 Locale 中间件, lang 查询参数, Accept-Language
+func (app App) GetLocale() string {
+	if locale := app.C.GetString(KeyLocale); locale != "" {
+		return locale
+	}
+	if locale := app.C.Query(QueryLocale); locale != "" && i18n.Supported(locale) {
+		return locale
+	}
+	return i18n.Negotiate(app.C.GetHeader(HeaderAcceptLanguage))
+}
+func (app App) T(msgID string, args ...any) string {
+	return i18n.T(app.GetLocale(), msgID, args...)
+}
+func (app App) translateResp(resp fastcurd.RetJSON) fastcurd.RetJSON {
+	resp.Msg = app.T(resp.Msg)
+	return resp
+}
+func SetLocale(c *gin.Context, locale string) {
+	c.Set(KeyLocale, locale)
 }
@@ -0,0 +1,236 @@
+package ginApp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/ugorji/go/codec"
+
+	"github.com/real-web-world/bdk/json"
+)
+
+const (
+	MIMEJSON    = "application/json"
+	MIMEXML     = "application/xml"
+	MIMETextXML = "text/xml"
+	MIMEMsgpack = "application/msgpack"
+	// MIMEXMsgpack 部分客户端使用的非标准类型
+	MIMEXMsgpack = "application/x-msgpack"
+	MIMECBOR     = "application/cbor"
+)
+
+var (
+	encoders   = make(map[string]Encoder)
+	encoderArr []string // 注册顺序 Accept权重相同时优先
+	encodersMu sync.RWMutex
+	// DefaultEncoder Accept 缺失或无法匹配时使用
+	DefaultEncoder Encoder = JSONEncoder{}
+	msgpackHandle          = &codec.MsgpackHandle{WriteExt: true}
+	cborHandle             = &codec.CborHandle{}
+)
+
+type (
+	// Encoder 将 RetJSON 编码为响应体
+	Encoder interface {
+		ContentType() string
+		Encode(w io.Writer, v any) error
+	}
+	JSONEncoder    struct{}
+	XMLEncoder     struct{}
+	MsgpackEncoder struct{}
+	CBOREncoder    struct{}
+	acceptItem     struct {
+		mediaType string
+		q         float64
+	}
+)
+
+func init() {
+	RegisterEncoder(MIMEJSON, JSONEncoder{})
+	RegisterEncoder(MIMEMsgpack, MsgpackEncoder{})
+	RegisterEncoder(MIMEXMsgpack, MsgpackEncoder{})
+	RegisterEncoder(MIMECBOR, CBOREncoder{})
+	RegisterEncoder(MIMEXML, XMLEncoder{})
+	RegisterEncoder(MIMETextXML, XMLEncoder{})
+}
+
+// RegisterEncoder 注册媒体类型对应的编码器 已存在时覆盖
+func RegisterEncoder(mediaType string, enc Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	mediaType = strings.ToLower(mediaType)
+	if _, ok := encoders[mediaType]; !ok {
+		encoderArr = append(encoderArr, mediaType)
+	}
+	encoders[mediaType] = enc
+}
+
+// NegotiateEncoder 按 Accept 的权重选择已注册的编码器
+func NegotiateEncoder(accept string) Encoder {
+	if accept == "" {
+		return DefaultEncoder
+	}
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	for _, item := range parseAccept(accept) {
+		if item.q <= 0 {
+			continue
+		}
+		switch {
+		case item.mediaType == "*/*":
+			return DefaultEncoder
+		case strings.HasSuffix(item.mediaType, "/*"):
+			prefix := strings.TrimSuffix(item.mediaType, "*")
+			if strings.HasPrefix(DefaultEncoder.ContentType(), prefix) {
+				return DefaultEncoder
+			}
+			for _, mediaType := range encoderArr {
+				if strings.HasPrefix(mediaType, prefix) {
+					return encoders[mediaType]
+				}
+			}
+		default:
+			if enc, ok := encoders[item.mediaType]; ok {
+				return enc
+			}
+		}
+	}
+	return DefaultEncoder
+}
+
+func parseAccept(accept string) []acceptItem {
+	list := make([]acceptItem, 0, 4)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qStr, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qStr, 64); err != nil {
+				continue
+			}
+		}
+		list = append(list, acceptItem{mediaType: mediaType, q: q})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].q > list[j].q
+	})
+	return list
+}
+
+func (JSONEncoder) ContentType() string {
+	return ContentTypeJSON
+}
+func (JSONEncoder) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+func (MsgpackEncoder) ContentType() string {
+	return MIMEMsgpack
+}
+func (MsgpackEncoder) Encode(w io.Writer, v any) error {
+	return codec.NewEncoder(w, msgpackHandle).Encode(v)
+}
+func (CBOREncoder) ContentType() string {
+	return MIMECBOR
+}
+func (CBOREncoder) Encode(w io.Writer, v any) error {
+	return codec.NewEncoder(w, cborHandle).Encode(v)
+}
+func (XMLEncoder) ContentType() string {
+	return MIMEXML + "; charset=utf-8"
+}
+
+// Encode 先按json标签转换为通用结构 以支持 Data 中的map 根元素为 response
+// 数字保留json中的原文 避免大整数经float64丢失精度
+func (XMLEncoder) Encode(w io.Writer, v any) error {
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var data any
+	dec := json.NewDecoder(bytes.NewReader(bts))
+	dec.UseNumber()
+	if err = dec.Decode(&data); err != nil {
+		return err
+	}
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err = encodeXMLElem(enc, "response", data); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func encodeXMLElem(enc *xml.Encoder, name string, val any) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlElemName(name)}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch val := val.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := encodeXMLElem(enc, key, val[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range val {
+			if err := encodeXMLElem(enc, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	case string:
+		if err := enc.EncodeToken(xml.CharData(val)); err != nil {
+			return err
+		}
+	default:
+		bts, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		if err = enc.EncodeToken(xml.CharData(bts)); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlElemName 将不合法的字符替换为_ 数字开头时加前缀_
+func xmlElemName(name string) string {
+	var sb strings.Builder
+	for i, c := range name {
+		isLetter := unicode.IsLetter(c) || c == '_'
+		isDigit := unicode.IsDigit(c) || c == '-' || c == '.'
+		switch {
+		case isLetter:
+			sb.WriteRune(c)
+		case isDigit && i > 0:
+			sb.WriteRune(c)
+		case isDigit:
+			sb.WriteRune('_')
+			sb.WriteRune(c)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	if sb.Len() == 0 {
+		return "_"
+	}
+	return sb.String()
+}
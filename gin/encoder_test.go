@@ -0,0 +1,108 @@
+package ginApp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/fastcurd"
+)
+
+func TestXMLEncoder(t *testing.T) {
+	cases := []struct {
+		name string
+		val  any
+		want string
+	}{
+		{name: "big int", val: map[string]any{"id": int64(1234567890123456789)}, want: "<response><id>1234567890123456789</id></response>"},
+		{name: "float", val: map[string]any{"price": 0.1}, want: "<response><price>0.1</price></response>"},
+		{name: "bool and null", val: map[string]any{"ok": true, "v": nil}, want: "<response><ok>true</ok><v></v></response>"},
+		{name: "list", val: []any{"a", 1}, want: "<response><item>a</item><item>1</item></response>"},
+		{name: "invalid name", val: map[string]any{"1a b": "x"}, want: "<response><_1a_b>x</_1a_b></response>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := (XMLEncoder{}).Encode(buf, tc.val); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimPrefix(buf.String(), xml.Header); got != tc.want {
+				t.Fatalf("Encode() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNegotiateEncoder(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: MIMEJSON},
+		{accept: "application/xml", want: MIMEXML},
+		{accept: "text/xml;q=0.5, application/json", want: MIMEJSON},
+		{accept: "application/cbor", want: MIMECBOR},
+		{accept: "image/png", want: MIMEJSON},
+	}
+	for _, tc := range cases {
+		if got := NegotiateEncoder(tc.accept).ContentType(); !strings.HasPrefix(got, tc.want) {
+			t.Errorf("NegotiateEncoder(%q) = %s, want %s", tc.accept, got, tc.want)
+		}
+	}
+}
+
+func TestWriteVaryAccept(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   ErrorFormat
+		accept   string
+		code     fastcurd.Code
+		preVary  string
+		wantVary []string
+		wantType string
+	}{
+		{name: "ok default", code: fastcurd.CodeOk, wantVary: []string{HeaderAccept}, wantType: MIMEJSON},
+		{name: "ok xml", accept: "application/xml", code: fastcurd.CodeOk, wantVary: []string{HeaderAccept}, wantType: MIMEXML},
+		{
+			name: "keep existing vary", code: fastcurd.CodeOk, preVary: "Accept-Encoding",
+			wantVary: []string{"Accept-Encoding", HeaderAccept}, wantType: MIMEJSON,
+		},
+		{name: "no duplicate", code: fastcurd.CodeOk, preVary: HeaderAccept, wantVary: []string{HeaderAccept}, wantType: MIMEJSON},
+		{
+			name: "error envelope", accept: "application/xml", code: fastcurd.CodeBadReq,
+			wantVary: []string{HeaderAccept}, wantType: MIMEXML,
+		},
+		{
+			name: "error negotiate problem", format: ErrorFormatNegotiate, accept: ContentTypeProblemJSON,
+			code: fastcurd.CodeBadReq, wantVary: []string{HeaderAccept}, wantType: ContentTypeProblemJSON,
+		},
+		{name: "error problem", format: ErrorFormatProblem, code: fastcurd.CodeBadReq, wantType: ContentTypeProblemJSON},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				c.Request.Header.Set(HeaderAccept, tc.accept)
+			}
+			if tc.preVary != "" {
+				c.Writer.Header().Add(HeaderVary, tc.preVary)
+			}
+			SetErrorFormat(c, tc.format)
+			GetApp(c).JSON(fastcurd.RetJSON{Code: tc.code})
+			if got := w.Header().Get(HeaderContentType); !strings.HasPrefix(got, tc.wantType) {
+				t.Fatalf("content type = %s, want %s", got, tc.wantType)
+			}
+			if got := w.Header().Values(HeaderVary); !slices.Equal(got, tc.wantVary) {
+				t.Fatalf("vary = %v, want %v", got, tc.wantVary)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/auth"
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+// Authenticate 依次尝试各认证器 均无凭证或凭证无效时响应 App.NoLogin
+// 认证器自身出错(如存储不可用)时响应 App.ServerError
+func Authenticate(authenticators ...auth.Authenticator) gin.HandlerFunc {
+	authenticator := auth.Chain(authenticators...)
+	return func(c *gin.Context) {
+		app := ginApp.GetApp(c)
+		p, err := authenticator.Authenticate(c.Request)
+		switch {
+		case err == nil:
+			ginApp.SetPrincipal(c, p)
+			c.Next()
+		case errors.Is(err, auth.ErrNoCredentials), errors.Is(err, auth.ErrInvalidCredentials):
+			_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+			app.NoLogin()
+		default:
+			app.ServerError(err)
+		}
+	}
+}
+
+// OptionalAuthenticate 凭证有效时写入调用方 否则以匿名身份继续
+func OptionalAuthenticate(authenticators ...auth.Authenticator) gin.HandlerFunc {
+	authenticator := auth.Chain(authenticators...)
+	return func(c *gin.Context) {
+		if p, err := authenticator.Authenticate(c.Request); err == nil {
+			ginApp.SetPrincipal(c, p)
+		} else if !errors.Is(err, auth.ErrNoCredentials) {
+			_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		}
+		c.Next()
+	}
+}
+
+// RequireLogin 配合 OptionalAuthenticate 保护部分路由
+func RequireLogin(c *gin.Context) {
+	app := ginApp.GetApp(c)
+	if !app.IsLogin() {
+		app.NoLogin()
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/real-web-world/bdk/errs"
+	"github.com/real-web-world/bdk/fastcurd"
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/i18n"
+)
+
+const (
+	HeaderContentEncoding = "Content-Encoding"
+	HeaderContentLength   = "Content-Length"
+	EncodingGzip          = "gzip"
+	EncodingDeflate       = "deflate"
+	EncodingZstd          = "zstd"
+	EncodingIdentity      = "identity"
+	// DefaultMaxBodySize 默认请求体上限 10MB
+	DefaultMaxBodySize int64 = 10 << 20
+	// DefaultMaxDecompressedSize 默认解压后的请求体上限 32MB
+	DefaultMaxDecompressedSize int64 = 32 << 20
+	// zstdMaxWindow http场景建议的最大窗口 8MB 限制解码内存
+	zstdMaxWindow = 8 << 20
+)
+
+type (
+	BodyLimitConfig struct {
+		// MaxSize 默认上限 为0时使用 DefaultMaxBodySize 为负数时不限制
+		MaxSize int64
+		// Routes 按路由覆盖上限 key为 c.FullPath() 或 "METHOD c.FullPath()"
+		Routes map[string]int64
+	}
+	DecompressConfig struct {
+		// MaxSize 解压后的上限 为0时使用 DefaultMaxDecompressedSize
+		MaxSize int64
+		// Encodings 允许的编码 为空时允许 gzip deflate zstd
+		Encodings []string
+	}
+	decompressBody struct {
+		io.Reader
+		closers []io.Closer
+	}
+)
+
+// BodyLimit 限制请求体大小 Content-Length 超限时直接响应413
+// 未声明长度的请求在读取超限时返回 *http.MaxBytesError 经 App.Error 响应413
+// 与 Decompress 同时使用时 先注册的 BodyLimit 限制的是压缩后的大小
+// 413与415均为 HonestStatus 下的状态码 兼容模式下为200 通过业务码区分
+func BodyLimit(cfg BodyLimitConfig) gin.HandlerFunc {
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = DefaultMaxBodySize
+	}
+	return func(c *gin.Context) {
+		maxSize := cfg.MaxSize
+		if routeSize, _, ok := matchRoute(c, cfg.Routes); ok {
+			maxSize = routeSize
+		}
+		if maxSize < 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > maxSize {
+			bodyTooLarge(c)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+		c.Next()
+	}
+}
+
+// Decompress 按 Content-Encoding 透明解压请求体 解压后超过 MaxSize 时读取返回 *http.MaxBytesError
+// 不支持的编码响应415
+func Decompress(cfg DecompressConfig) gin.HandlerFunc {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultMaxDecompressedSize
+	}
+	if len(cfg.Encodings) == 0 {
+		cfg.Encodings = []string{EncodingGzip, EncodingDeflate, EncodingZstd}
+	}
+	return func(c *gin.Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.GetHeader(HeaderContentEncoding)))
+		if encoding == "" || encoding == EncodingIdentity || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if encoding == "x-gzip" {
+			encoding = EncodingGzip
+		}
+		if !containsFold(cfg.Encodings, encoding) {
+			unsupportedEncoding(c, encoding)
+			return
+		}
+		body, err := newDecompressBody(c.Request.Body, encoding)
+		if err != nil {
+			ginApp.GetApp(c).Error(errs.Wrap(err, fastcurd.CodeBadReq, i18n.MsgBadReq).
+				WithStatus(http.StatusBadRequest))
+			return
+		}
+		defer body.Close()
+		c.Request.Body = http.MaxBytesReader(c.Writer, body, cfg.MaxSize)
+		c.Request.Header.Del(HeaderContentEncoding)
+		c.Request.Header.Del(HeaderContentLength)
+		c.Request.ContentLength = -1
+		c.Next()
+	}
+}
+
+func newDecompressBody(src io.ReadCloser, encoding string) (*decompressBody, error) {
+	body := &decompressBody{closers: []io.Closer{src}}
+	switch encoding {
+	case EncodingGzip:
+		r, err := gzip.NewReader(src)
+		if err != nil {
+			return nil, err
+		}
+		body.Reader = r
+		body.closers = append(body.closers, r)
+	case EncodingDeflate:
+		// 规范中deflate为zlib格式 部分客户端发送裸deflate 按首字节判断
+		br := bufio.NewReader(src)
+		if header, err := br.Peek(2); err == nil && isZlibHeader(header) {
+			r, err := zlib.NewReader(br)
+			if err != nil {
+				return nil, err
+			}
+			body.Reader = r
+			body.closers = append(body.closers, r)
+		} else {
+			r := flate.NewReader(br)
+			body.Reader = r
+			body.closers = append(body.closers, r)
+		}
+	case EncodingZstd:
+		r, err := zstd.NewReader(src,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxWindow(zstdMaxWindow),
+		)
+		if err != nil {
+			return nil, err
+		}
+		body.Reader = r
+		body.closers = append(body.closers, r.IOReadCloser())
+	}
+	return body, nil
+}
+func (b *decompressBody) Close() error {
+	var err error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if closeErr := b.closers[i].Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	b.closers = nil
+	return err
+}
+
+func isZlibHeader(header []byte) bool {
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
+func containsFold(list []string, str string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, str) {
+			return true
+		}
+	}
+	return false
+}
+func bodyTooLarge(c *gin.Context) {
+	ginApp.GetApp(c).Error(errs.New(fastcurd.CodePayloadTooLarge, ""))
+}
+func unsupportedEncoding(c *gin.Context, encoding string) {
+	ginApp.GetApp(c).Error(errs.New(fastcurd.CodeBadReq, i18n.MsgUnsupportedEncoding, encoding).
+		WithStatus(http.StatusUnsupportedMediaType))
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/real-web-world/bdk/fastcurd"
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/json"
+)
+
+func gzipBody(t *testing.T, str string) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write([]byte(str)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestBodyLimit(t *testing.T) {
+	type params struct {
+		Name string `json:"name" binding:"required"`
+	}
+	body := `{"name":"` + strings.Repeat("a", 64) + `"}`
+	cases := []struct {
+		name       string
+		chunked    bool // 不声明 Content-Length 读取时才超限
+		gzip       bool
+		maxSize    int64
+		maxDecomp  int64
+		wantStatus int
+		wantCode   fastcurd.Code
+	}{
+		{name: "within limit", maxSize: 1024, wantStatus: http.StatusOK, wantCode: fastcurd.CodeOk},
+		{name: "content length too large", maxSize: 16, wantStatus: http.StatusRequestEntityTooLarge, wantCode: fastcurd.CodePayloadTooLarge},
+		{name: "chunked too large", chunked: true, maxSize: 16, wantStatus: http.StatusRequestEntityTooLarge, wantCode: fastcurd.CodePayloadTooLarge},
+		{name: "gzip within limit", gzip: true, maxSize: 1024, wantStatus: http.StatusOK, wantCode: fastcurd.CodeOk},
+		{name: "gzip decompressed too large", gzip: true, maxSize: 1024, maxDecomp: 16, wantStatus: http.StatusRequestEntityTooLarge, wantCode: fastcurd.CodePayloadTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(HonestStatus, BodyLimit(BodyLimitConfig{MaxSize: tc.maxSize}),
+				Decompress(DecompressConfig{MaxSize: tc.maxDecomp}))
+			r.POST("/", func(c *gin.Context) {
+				app := ginApp.GetApp(c)
+				var p params
+				if err := c.ShouldBindJSON(&p); err != nil {
+					app.ValidError(err)
+					return
+				}
+				app.Data(p.Name)
+			})
+			var reqBody *bytes.Buffer
+			if tc.gzip {
+				reqBody = gzipBody(t, body)
+			} else {
+				reqBody = bytes.NewBufferString(body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", reqBody)
+			req.Header.Set(ginApp.HeaderContentType, "application/json")
+			if tc.gzip {
+				req.Header.Set(HeaderContentEncoding, EncodingGzip)
+			}
+			if tc.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d body %s", w.Code, tc.wantStatus, w.Body.String())
+			}
+			var resp fastcurd.RetJSON
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %d, want %d", resp.Code, tc.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+const (
+	HeaderAcceptEncoding = "Accept-Encoding"
+	// DefaultCompressMinLength 小于该长度的响应不压缩
+	DefaultCompressMinLength = 1 << 10
+)
+
+var (
+	// DefaultCompressExcludeContentTypes 流式或已压缩的类型
+	DefaultCompressExcludeContentTypes = []string{
+		ginApp.ContentTypeEventStream, ginApp.ContentTypeNDJSON,
+		"image/", "video/", "audio/", "application/zip", "application/gzip", "application/zstd",
+	}
+)
+
+type (
+	CompressConfig struct {
+		// Encodings 服务端偏好顺序 Accept-Encoding 权重相同时优先 为空时为 zstd gzip deflate
+		Encodings []string
+		// Level gzip与deflate的压缩级别 为0时使用默认级别
+		Level int
+		// MinLength 为0时使用 DefaultCompressMinLength
+		MinLength int
+		// ExcludeContentTypes 按前缀匹配 为空时使用 DefaultCompressExcludeContentTypes
+		ExcludeContentTypes []string
+	}
+	resetWriteCloser interface {
+		io.WriteCloser
+		Reset(w io.Writer)
+		Flush() error
+	}
+	compressWriter struct {
+		gin.ResponseWriter
+		encoding  string
+		pool      *sync.Pool
+		cfg       *CompressConfig
+		buf       []byte
+		enc       resetWriteCloser
+		decided   bool
+		headReq   bool
+		closeOnce bool
+	}
+)
+
+// Compress 按 Accept-Encoding 压缩响应 小于 MinLength 的响应先缓冲 达到长度或结束时再决定是否压缩
+// 已设置 Content-Encoding 或类型被排除的响应原样输出
+func Compress(cfg CompressConfig) gin.HandlerFunc {
+	if len(cfg.Encodings) == 0 {
+		cfg.Encodings = []string{EncodingZstd, EncodingGzip, EncodingDeflate}
+	}
+	if cfg.Level == 0 {
+		cfg.Level = gzip.DefaultCompression
+	}
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = DefaultCompressMinLength
+	}
+	if len(cfg.ExcludeContentTypes) == 0 {
+		cfg.ExcludeContentTypes = DefaultCompressExcludeContentTypes
+	}
+	pools := make(map[string]*sync.Pool, len(cfg.Encodings))
+	for _, encoding := range cfg.Encodings {
+		if pool := newEncoderPool(encoding, cfg.Level); pool != nil {
+			pools[encoding] = pool
+		}
+	}
+	return func(c *gin.Context) {
+		c.Writer.Header().Add(HeaderVary, HeaderAcceptEncoding)
+		encoding := negotiateEncoding(c.GetHeader(HeaderAcceptEncoding), cfg.Encodings)
+		pool, ok := pools[encoding]
+		if !ok {
+			c.Next()
+			return
+		}
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			pool:           pool,
+			cfg:            &cfg,
+			headReq:        c.Request.Method == http.MethodHead,
+		}
+		c.Writer = w
+		defer func() {
+			w.close()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+func newEncoderPool(encoding string, level int) *sync.Pool {
+	switch encoding {
+	case EncodingGzip:
+		return &sync.Pool{New: func() any {
+			w, err := gzip.NewWriterLevel(io.Discard, level)
+			if err != nil {
+				w = gzip.NewWriter(io.Discard)
+			}
+			return w
+		}}
+	case EncodingDeflate:
+		return &sync.Pool{New: func() any {
+			w, err := flate.NewWriter(io.Discard, level)
+			if err != nil {
+				w, _ = flate.NewWriter(io.Discard, flate.DefaultCompression)
+			}
+			return w
+		}}
+	case EncodingZstd:
+		return &sync.Pool{New: func() any {
+			w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+			return w
+		}}
+	}
+	return nil
+}
+
+// negotiateEncoding 选出q值最高的编码 q相同时按服务端偏好 无可用编码时返回空
+func negotiateEncoding(acceptEncoding string, preferred []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	qMap := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if qStr, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(qStr, 64); err != nil {
+				continue
+			}
+		}
+		qMap[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+	best, bestQ := "", 0.0
+	for _, encoding := range preferred {
+		q, ok := qMap[encoding]
+		if !ok {
+			q, ok = qMap["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+func (w *compressWriter) Write(bts []byte) (int, error) {
+	if !w.decided {
+		if len(w.buf)+len(bts) < w.cfg.MinLength {
+			w.buf = append(w.buf, bts...)
+			return len(bts), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	if w.enc != nil {
+		return w.enc.Write(bts)
+	}
+	return w.ResponseWriter.Write(bts)
+}
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written 缓冲中的数据视为已写入 避免gin重复写入响应头
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide 决定是否压缩并输出缓冲 enough为false时表示长度未达到 MinLength
+func (w *compressWriter) decide(enough bool) error {
+	w.decided = true
+	buf := w.buf
+	w.buf = nil
+	if enough && w.compressible() {
+		header := w.Header()
+		header.Set(HeaderContentEncoding, w.encoding)
+		header.Del(HeaderContentLength)
+		w.enc = w.pool.Get().(resetWriteCloser)
+		w.enc.Reset(w.ResponseWriter)
+		if len(buf) == 0 {
+			return nil
+		}
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+func (w *compressWriter) compressible() bool {
+	if w.headReq {
+		return false
+	}
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get(HeaderContentEncoding) != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get(ginApp.HeaderContentType))
+	for _, exclude := range w.cfg.ExcludeContentTypes {
+		if strings.HasPrefix(contentType, exclude) {
+			return false
+		}
+	}
+	return true
+}
+func (w *compressWriter) close() {
+	if w.closeOnce {
+		return
+	}
+	w.closeOnce = true
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.enc.Reset(io.Discard)
+		w.pool.Put(w.enc)
+		w.enc = nil
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	preferred := []string{EncodingZstd, EncodingGzip, EncodingDeflate}
+	cases := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: ""},
+		{accept: "gzip", want: EncodingGzip},
+		{accept: "gzip, deflate, br, zstd", want: EncodingZstd},
+		{accept: "GZIP", want: EncodingGzip},
+		{accept: "gzip;q=0.5, deflate;q=0.8", want: EncodingDeflate},
+		{accept: "gzip;q=0, deflate", want: EncodingDeflate},
+		{accept: "*", want: EncodingZstd},
+		{accept: "zstd;q=0, *;q=0.1", want: EncodingGzip},
+		{accept: "br", want: ""},
+		{accept: "gzip;q=abc", want: ""},
+		{accept: "identity", want: ""},
+	}
+	for _, tc := range cases {
+		if got := negotiateEncoding(tc.accept, preferred); got != tc.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tc.accept, got, tc.want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	long := strings.Repeat("hello ", 512)
+	cases := []struct {
+		name         string
+		body         string
+		contentType  string
+		wantEncoding string
+	}{
+		{name: "compressed", body: long, contentType: "text/plain", wantEncoding: EncodingGzip},
+		{name: "below min length", body: "short", contentType: "text/plain"},
+		{name: "excluded type", body: long, contentType: "image/png"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Compress(CompressConfig{}))
+			r.GET("/", func(c *gin.Context) {
+				c.Data(http.StatusOK, tc.contentType, []byte(tc.body))
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(HeaderAcceptEncoding, "gzip")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if got := w.Header().Get(HeaderContentEncoding); got != tc.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tc.wantEncoding)
+			}
+			var reader io.Reader = w.Body
+			if tc.wantEncoding == EncodingGzip {
+				gr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				reader = gr
+			}
+			bts, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bts) != tc.body {
+				t.Fatalf("body mismatch, got %d bytes want %d", len(bts), len(tc.body))
+			}
+		})
+	}
+}
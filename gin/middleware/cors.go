@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk"
+	"github.com/real-web-world/bdk/auth"
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+const (
+	HeaderOrigin                        = "Origin"
+	HeaderVary                          = "Vary"
+	HeaderAccessControlAllowOrigin      = "Access-Control-Allow-Origin"
+	HeaderAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	HeaderAccessControlAllowMethods     = "Access-Control-Allow-Methods"
+	HeaderAccessControlAllowHeaders     = "Access-Control-Allow-Headers"
+	HeaderAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
+	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
+	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
+	HeaderAccessControlRequestHeaders   = "Access-Control-Request-Headers"
+)
+
+const (
+	HeaderStrictTransportSecurity = "Strict-Transport-Security"
+	HeaderContentSecurityPolicy   = "Content-Security-Policy"
+	HeaderXContentTypeOptions     = "X-Content-Type-Options"
+	HeaderXFrameOptions           = "X-Frame-Options"
+	HeaderReferrerPolicy          = "Referrer-Policy"
+)
+
+var (
+	DefaultCORSMethods = []string{
+		http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead,
+	}
+	DefaultCORSHeaders = []string{
+		HeaderOrigin, ginApp.HeaderAccept, ginApp.HeaderContentType, ginApp.HeaderAcceptLanguage,
+		auth.HeaderAuthorization, auth.DefaultAPIKeyHeader, bdk.HeadReqID, HeaderIdempotencyKey,
+	}
+	DefaultCORSExposeHeaders = []string{
+		bdk.HeadReqID, ginApp.HeaderContentLanguage, HeaderRetryAfter,
+		HeaderRateLimitLimit, HeaderRateLimitRemaining, HeaderRateLimitReset, HeaderIdempotentReplayed,
+	}
+	// DefaultSecurityHeadersConfig HSTS一年 禁止被嵌入 禁止MIME嗅探
+	DefaultSecurityHeadersConfig = SecurityHeadersConfig{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		FrameOptions:          "DENY",
+		ContentTypeNosniff:    true,
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+	}
+)
+
+type (
+	CORSConfig struct {
+		// AllowOrigins 允许的来源 "*"为全部 "https://*.example.com"匹配任意子域名(不含example.com本身)
+		// 省略协议的"*.example.com"视为 https "*"不能与 AllowCredentials 同时使用
+		AllowOrigins []string
+		// AllowOriginFunc 不为nil时 AllowOrigins 未匹配的来源交由其判断
+		AllowOriginFunc func(origin string) bool
+		// AllowMethods 为空时使用 DefaultCORSMethods
+		AllowMethods []string
+		// AllowHeaders 为空时使用 DefaultCORSHeaders 包含"*"时回显预检请求的头
+		AllowHeaders []string
+		// ExposeHeaders 为空时使用 DefaultCORSExposeHeaders
+		ExposeHeaders []string
+		// AllowCredentials 为true时回显具体来源 而不是"*" 来源需逐个列出或由 AllowOriginFunc 判断
+		AllowCredentials bool
+		// MaxAge 预检结果缓存时间 为0时不设置
+		MaxAge time.Duration
+	}
+	SecurityHeadersConfig struct {
+		// HSTSMaxAge 为0时不设置 Strict-Transport-Security
+		HSTSMaxAge            time.Duration
+		HSTSIncludeSubdomains bool
+		HSTSPreload           bool
+		// ContentSecurityPolicy 为空时不设置
+		ContentSecurityPolicy string
+		// FrameOptions DENY 或 SAMEORIGIN 为空时不设置
+		FrameOptions       string
+		ContentTypeNosniff bool
+		ReferrerPolicy     string
+	}
+	corsOrigin struct {
+		exact  string
+		scheme string // 通配子域名时的协议 含://
+		suffix string // 通配子域名时的后缀 含前导.
+	}
+)
+
+// CORS 跨域资源共享 预检请求在此结束 响应204 来源不允许时响应403
+// 非预检请求的来源不允许时不设置跨域头 由浏览器拦截
+// AllowOrigins 格式错误或"*"与 AllowCredentials 同时使用时panic
+func CORS(cfg CORSConfig) gin.HandlerFunc {
+	if len(cfg.AllowMethods) == 0 {
+		cfg.AllowMethods = DefaultCORSMethods
+	}
+	if len(cfg.AllowHeaders) == 0 {
+		cfg.AllowHeaders = DefaultCORSHeaders
+	}
+	if len(cfg.ExposeHeaders) == 0 {
+		cfg.ExposeHeaders = DefaultCORSExposeHeaders
+	}
+	allowAll := false
+	origins := make([]corsOrigin, 0, len(cfg.AllowOrigins))
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			allowAll = true
+			continue
+		}
+		origins = append(origins, mustParseCORSOrigin(origin))
+	}
+	if allowAll && cfg.AllowCredentials {
+		// 回显任意来源并允许携带凭证 等同于允许任意站点读取用户数据
+		panic(`cors: AllowOrigins "*" cannot be used with AllowCredentials`)
+	}
+	allowMethods := strings.Join(cfg.AllowMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowHeaders, ", ")
+	reflectHeaders := allowHeaders == "*"
+	exposeHeaders := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAge := ""
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+	allowed := func(origin string) bool {
+		if allowAll {
+			return true
+		}
+		lowerOrigin := strings.ToLower(origin)
+		for _, item := range origins {
+			if item.match(lowerOrigin) {
+				return true
+			}
+		}
+		return cfg.AllowOriginFunc != nil && cfg.AllowOriginFunc(origin)
+	}
+	return func(c *gin.Context) {
+		origin := c.GetHeader(HeaderOrigin)
+		if origin == "" {
+			c.Next()
+			return
+		}
+		header := c.Writer.Header()
+		header.Add(HeaderVary, HeaderOrigin)
+		preflight := c.Request.Method == http.MethodOptions &&
+			c.GetHeader(HeaderAccessControlRequestMethod) != ""
+		if preflight {
+			header.Add(HeaderVary, HeaderAccessControlRequestMethod)
+			header.Add(HeaderVary, HeaderAccessControlRequestHeaders)
+		}
+		if !allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+		if allowAll {
+			header.Set(HeaderAccessControlAllowOrigin, "*")
+		} else {
+			header.Set(HeaderAccessControlAllowOrigin, origin)
+		}
+		if cfg.AllowCredentials {
+			header.Set(HeaderAccessControlAllowCredentials, "true")
+		}
+		if !preflight {
+			header.Set(HeaderAccessControlExposeHeaders, exposeHeaders)
+			c.Next()
+			return
+		}
+		header.Set(HeaderAccessControlAllowMethods, allowMethods)
+		if reflectHeaders {
+			if reqHeaders := c.GetHeader(HeaderAccessControlRequestHeaders); reqHeaders != "" {
+				header.Set(HeaderAccessControlAllowHeaders, reqHeaders)
+			}
+		} else {
+			header.Set(HeaderAccessControlAllowHeaders, allowHeaders)
+		}
+		if maxAge != "" {
+			header.Set(HeaderAccessControlMaxAge, maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// SecurityHeaders 设置常用安全响应头
+func SecurityHeaders(cfg SecurityHeadersConfig) gin.HandlerFunc {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		if hsts != "" {
+			header.Set(HeaderStrictTransportSecurity, hsts)
+		}
+		if cfg.ContentSecurityPolicy != "" {
+			header.Set(HeaderContentSecurityPolicy, cfg.ContentSecurityPolicy)
+		}
+		if cfg.FrameOptions != "" {
+			header.Set(HeaderXFrameOptions, cfg.FrameOptions)
+		}
+		if cfg.ContentTypeNosniff {
+			header.Set(HeaderXContentTypeOptions, "nosniff")
+		}
+		if cfg.ReferrerPolicy != "" {
+			header.Set(HeaderReferrerPolicy, cfg.ReferrerPolicy)
+		}
+		c.Next()
+	}
+}
+
+func mustParseCORSOrigin(origin string) corsOrigin {
+	origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+	if strings.HasPrefix(origin, "*.") {
+		origin = "https://" + origin
+	}
+	if scheme, host, ok := strings.Cut(origin, "://*."); ok && scheme != "" && host != "" &&
+		!strings.Contains(scheme, "*") && !strings.Contains(host, "*") {
+		return corsOrigin{scheme: scheme + "://", suffix: "." + host}
+	}
+	if origin == "" || strings.Contains(origin, "*") || !strings.Contains(origin, "://") {
+		panic(fmt.Sprintf("cors: invalid origin %q", origin))
+	}
+	return corsOrigin{exact: origin}
+}
+func (o corsOrigin) match(origin string) bool {
+	if o.exact != "" {
+		return o.exact == origin
+	}
+	host, ok := strings.CutPrefix(origin, o.scheme)
+	return ok && len(host) > len(o.suffix) && strings.HasSuffix(host, o.suffix)
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSOriginMatch(t *testing.T) {
+	cases := []struct {
+		name    string
+		allow   []string
+		origin  string
+		allowed bool
+	}{
+		{name: "exact", allow: []string{"https://a.com"}, origin: "https://a.com", allowed: true},
+		{name: "exact case insensitive", allow: []string{"https://A.com/"}, origin: "https://a.COM", allowed: true},
+		{name: "exact other scheme", allow: []string{"https://a.com"}, origin: "http://a.com"},
+		{name: "wildcard subdomain", allow: []string{"https://*.a.com"}, origin: "https://x.a.com", allowed: true},
+		{name: "wildcard nested", allow: []string{"https://*.a.com"}, origin: "https://x.y.a.com", allowed: true},
+		{name: "wildcard apex", allow: []string{"https://*.a.com"}, origin: "https://a.com"},
+		{name: "wildcard lookalike", allow: []string{"https://*.a.com"}, origin: "https://xa.com"},
+		{name: "wildcard suffix attack", allow: []string{"https://*.a.com"}, origin: "https://x.a.com.evil.com"},
+		{name: "wildcard without scheme", allow: []string{"*.a.com"}, origin: "https://x.a.com", allowed: true},
+		{name: "wildcard without scheme http", allow: []string{"*.a.com"}, origin: "http://x.a.com"},
+		{name: "all", allow: []string{"*"}, origin: "https://evil.com", allowed: true},
+		{name: "none", origin: "https://a.com"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(CORS(CORSConfig{AllowOrigins: tc.allow}))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+			req := httptest.NewRequest(http.MethodOptions, "/", nil)
+			req.Header.Set(HeaderOrigin, tc.origin)
+			req.Header.Set(HeaderAccessControlRequestMethod, http.MethodGet)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if allowed := w.Code == http.StatusNoContent; allowed != tc.allowed {
+				t.Fatalf("allowed = %v (status %d), want %v", allowed, w.Code, tc.allowed)
+			}
+		})
+	}
+}
+
+func TestCORSCredentials(t *testing.T) {
+	r := gin.New()
+	r.Use(CORS(CORSConfig{AllowOrigins: []string{"https://a.com"}, AllowCredentials: true}))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderOrigin, "https://a.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get(HeaderAccessControlAllowOrigin); got != "https://a.com" {
+		t.Fatalf("allow origin = %q", got)
+	}
+	if got := w.Header().Get(HeaderAccessControlAllowCredentials); got != "true" {
+		t.Fatalf("allow credentials = %q", got)
+	}
+}
+
+func TestCORSInvalidConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  CORSConfig
+	}{
+		{name: "all with credentials", cfg: CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true}},
+		{name: "no scheme", cfg: CORSConfig{AllowOrigins: []string{"a.com"}}},
+		{name: "inner wildcard", cfg: CORSConfig{AllowOrigins: []string{"https://a.*.com"}}},
+		{name: "empty host", cfg: CORSConfig{AllowOrigins: []string{"https://*."}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected panic")
+				}
+			}()
+			CORS(tc.cfg)
+		})
+	}
+}
@@ -1,17 +1,217 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"fmt"
 	"net"
 	"net/http"
+	"net/netip"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk"
+	"github.com/real-web-world/bdk/auth"
+)
+
+const (
+	HeaderXForwardedFor   = "X-Forwarded-For"
+	HeaderXRealIP         = "X-Real-IP"
+	HeaderForwarded       = "Forwarded"
+	HeaderWWWAuthenticate = "WWW-Authenticate"
+	DefaultDevTokenHeader = "X-Dev-Token"
+	devAccessRealm        = "dev"
+)
+
+var (
+	// DefaultDevAllowCIDRs 私有网络与回环地址
+	DefaultDevAllowCIDRs = []string{
+		"127.0.0.0/8", "::1/128",
+		"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7",
+	}
+	// DefaultDevPrefixes 默认保护的路由前缀
+	DefaultDevPrefixes = []string{bdk.DebugApiPrefix, bdk.SwaggerApiPrefix}
+	defaultDevAccess   = DevAccessWith(DevAccessConfig{All: true})
 )
 
+type (
+	DevAccessConfig struct {
+		// Allow 允许访问的网段 为空时使用 DefaultDevAllowCIDRs 单个ip视为/32或/128
+		Allow []string
+		// Deny 拒绝访问的网段 优先于 Allow 与凭证
+		Deny []string
+		// TrustedProxies 可信代理网段 仅当直连地址属于可信代理时才采信 X-Forwarded-For
+		// 直连地址不是可信代理却携带了转发头时 视为经过未知代理 Allow 不生效 只能凭凭证访问
+		// 部署在反向代理 负载均衡或sidecar之后时必须配置 不受 gin 可信代理配置影响
+		TrustedProxies []string
+		// BasicAuth 用户名->密码 ip不在 Allow 内时可通过 basic auth 访问
+		BasicAuth map[string]string
+		// Tokens ip不在 Allow 内时可通过 Authorization: Bearer 或 TokenHeader 携带令牌访问
+		Tokens      []string
+		TokenHeader string // 为空时使用 DefaultDevTokenHeader
+		// Prefixes 受保护的路由前缀 为空时使用 DefaultDevPrefixes
+		// 作为全局中间件时只检查路由模板(c.FullPath)在这些前缀下的请求 其余请求直接放行
+		Prefixes []string
+		// All 为true时检查全部请求 用于直接挂载到路由组
+		All bool
+	}
+	devAccessGuard struct {
+		cfg            DevAccessConfig
+		allow          []netip.Prefix
+		deny           []netip.Prefix
+		trustedProxies []netip.Prefix
+	}
+)
+
+// DevAccess 仅允许私有网络与回环地址直连访问 携带转发头的请求一律拒绝
+// 部署在代理之后时改用 DevAccessWith 并配置 TrustedProxies
 func DevAccess(c *gin.Context) {
-	ip := net.ParseIP(c.ClientIP())
-	if !ip.IsPrivate() && !ip.IsLoopback() {
-		c.AbortWithStatus(http.StatusNotFound)
+	defaultDevAccess(c)
+}
+
+// DevAccessWith 可配置的开发接口访问控制 拒绝时响应404以隐藏接口
+// 配置了 BasicAuth 且未携带凭证时响应401 以便浏览器弹出登录框
+// 网段格式错误时panic
+func DevAccessWith(cfg DevAccessConfig) gin.HandlerFunc {
+	if len(cfg.Allow) == 0 {
+		cfg.Allow = DefaultDevAllowCIDRs
+	}
+	if len(cfg.Prefixes) == 0 {
+		cfg.Prefixes = DefaultDevPrefixes
+	}
+	if cfg.TokenHeader == "" {
+		cfg.TokenHeader = DefaultDevTokenHeader
+	}
+	g := &devAccessGuard{
+		cfg:            cfg,
+		allow:          mustParsePrefixes(cfg.Allow),
+		deny:           mustParsePrefixes(cfg.Deny),
+		trustedProxies: mustParsePrefixes(cfg.TrustedProxies),
+	}
+	return g.handle
+}
+
+func (g *devAccessGuard) handle(c *gin.Context) {
+	if !g.cfg.All && !g.protected(c) {
+		c.Next()
 		return
 	}
-	c.Next()
+	ip, ok := g.clientIP(c.Request)
+	switch {
+	case ip.IsValid() && containsIP(g.deny, ip):
+		c.AbortWithStatus(http.StatusNotFound)
+	case ok && containsIP(g.allow, ip), g.validCredential(c.Request):
+		c.Next()
+	case len(g.cfg.BasicAuth) > 0:
+		c.Header(HeaderWWWAuthenticate, `Basic realm="`+devAccessRealm+`", charset="UTF-8"`)
+		c.AbortWithStatus(http.StatusUnauthorized)
+	default:
+		c.AbortWithStatus(http.StatusNotFound)
+	}
+}
+
+// protected 按路由模板匹配 与实际命中的路由一致 不受重复斜杠等请求路径写法影响
+// 未命中路由时按请求路径匹配
+func (g *devAccessGuard) protected(c *gin.Context) bool {
+	reqPath := c.FullPath()
+	if reqPath == "" {
+		reqPath = c.Request.URL.Path
+	}
+	for _, prefix := range g.cfg.Prefixes {
+		if reqPath == prefix || strings.HasPrefix(reqPath, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP 从直连地址开始 由右向左跳过可信代理 取第一个不可信的地址
+// 无法确定真实地址时返回false 此时只能凭凭证访问 返回的地址仍用于 Deny 判断
+func (g *devAccessGuard) clientIP(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	ip = ip.Unmap()
+	if !containsIP(g.trustedProxies, ip) {
+		// 直连方是未配置的代理时 私有的直连地址不代表真实客户端
+		return ip, !hasForwardedHeader(r)
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values(HeaderXForwardedFor), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hopIP, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		ip = hopIP.Unmap()
+		if !containsIP(g.trustedProxies, ip) {
+			return ip, true
+		}
+	}
+	return ip, true
+}
+func hasForwardedHeader(r *http.Request) bool {
+	return r.Header.Get(HeaderXForwardedFor) != "" || r.Header.Get(HeaderXRealIP) != "" ||
+		r.Header.Get(HeaderForwarded) != ""
+}
+func (g *devAccessGuard) validCredential(r *http.Request) bool {
+	if user, pwd, ok := r.BasicAuth(); ok {
+		if expected, exist := g.cfg.BasicAuth[user]; exist && secureEqual(pwd, expected) {
+			return true
+		}
+	}
+	if len(g.cfg.Tokens) == 0 {
+		return false
+	}
+	token := r.Header.Get(g.cfg.TokenHeader)
+	if token == "" {
+		token = auth.BearerToken(r)
+	}
+	if token == "" {
+		return false
+	}
+	valid := false
+	for _, expected := range g.cfg.Tokens {
+		// 遍历全部令牌 避免通过耗时推断匹配位置
+		if secureEqual(token, expected) {
+			valid = true
+		}
+	}
+	return valid
+}
+
+func secureEqual(actual, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) == 1
+}
+func containsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+func mustParsePrefixes(cidrArr []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrArr))
+	for _, cidr := range cidrArr {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			ip, err := netip.ParseAddr(cidr)
+			if err != nil {
+				panic(fmt.Sprintf("dev access: invalid ip %q: %v", cidr, err))
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("dev access: invalid cidr %q: %v", cidr, err))
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
 }
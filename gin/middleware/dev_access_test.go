@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestDevAccess(t *testing.T) {
+	cases := []struct {
+		name       string
+		cfg        *DevAccessConfig
+		remoteAddr string
+		header     map[string]string
+		path       string
+		want       int
+	}{
+		{name: "loopback", remoteAddr: "127.0.0.1:1234", want: http.StatusOK},
+		{name: "private", remoteAddr: "10.1.2.3:1234", want: http.StatusOK},
+		{name: "public", remoteAddr: "8.8.8.8:1234", want: http.StatusNotFound},
+		{name: "ipv4 mapped", remoteAddr: "[::ffff:192.168.1.2]:1234", want: http.StatusOK},
+		{
+			name: "private peer with forwarded header", remoteAddr: "10.0.0.2:1234",
+			header: map[string]string{HeaderXForwardedFor: "8.8.8.8"}, want: http.StatusNotFound,
+		},
+		{
+			name: "private peer with forged private forwarded", remoteAddr: "10.0.0.2:1234",
+			header: map[string]string{HeaderXForwardedFor: "127.0.0.1"}, want: http.StatusNotFound,
+		},
+		{
+			name: "private peer with x-real-ip", remoteAddr: "10.0.0.2:1234",
+			header: map[string]string{HeaderXRealIP: "8.8.8.8"}, want: http.StatusNotFound,
+		},
+		{
+			name: "trusted proxy public client",
+			cfg:  &DevAccessConfig{All: true, TrustedProxies: []string{"10.0.0.0/8"}}, remoteAddr: "10.0.0.2:1234",
+			header: map[string]string{HeaderXForwardedFor: "8.8.8.8, 10.0.0.3"}, want: http.StatusNotFound,
+		},
+		{
+			name: "trusted proxy private client",
+			cfg:  &DevAccessConfig{All: true, TrustedProxies: []string{"10.0.0.0/8"}}, remoteAddr: "10.0.0.2:1234",
+			header: map[string]string{HeaderXForwardedFor: "8.8.8.8, 192.168.1.1"}, want: http.StatusOK,
+		},
+		{
+			name: "spoofed forwarded from public peer",
+			cfg:  &DevAccessConfig{All: true, TrustedProxies: []string{"10.0.0.0/8"}}, remoteAddr: "8.8.8.8:1234",
+			header: map[string]string{HeaderXForwardedFor: "127.0.0.1"}, want: http.StatusNotFound,
+		},
+		{
+			name: "deny wins",
+			cfg:  &DevAccessConfig{All: true, Deny: []string{"10.0.0.5"}}, remoteAddr: "10.0.0.5:1234",
+			want: http.StatusNotFound,
+		},
+		{
+			name: "token behind unknown proxy",
+			cfg:  &DevAccessConfig{All: true, Tokens: []string{"secret"}}, remoteAddr: "10.0.0.2:1234",
+			header: map[string]string{HeaderXForwardedFor: "8.8.8.8", DefaultDevTokenHeader: "secret"},
+			want:   http.StatusOK,
+		},
+		{
+			name: "wrong token",
+			cfg:  &DevAccessConfig{All: true, Tokens: []string{"secret"}}, remoteAddr: "8.8.8.8:1234",
+			header: map[string]string{"Authorization": "Bearer nope"}, want: http.StatusNotFound,
+		},
+		{
+			name: "basic auth challenge",
+			cfg:  &DevAccessConfig{All: true, BasicAuth: map[string]string{"dev": "pwd"}}, remoteAddr: "8.8.8.8:1234",
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "unprotected prefix",
+			cfg:  &DevAccessConfig{}, remoteAddr: "8.8.8.8:1234", path: "/api/ping", want: http.StatusOK,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := DevAccess
+			if tc.cfg != nil {
+				handler = DevAccessWith(*tc.cfg)
+			}
+			path := tc.path
+			if path == "" {
+				path = "/debug/pprof"
+			}
+			r := gin.New()
+			r.GET(path, handler, func(c *gin.Context) { c.Status(http.StatusOK) })
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.RemoteAddr = tc.remoteAddr
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestDevAccessRouteTemplate(t *testing.T) {
+	cases := []struct {
+		name string
+		path string
+		want int
+	}{
+		{name: "param route", path: "/debug/pprof/heap", want: http.StatusNotFound},
+		{name: "duplicate slash", path: "//debug/pprof/heap", want: http.StatusNotFound},
+		{name: "swagger", path: "/swagger/index.html", want: http.StatusNotFound},
+		{name: "unprotected", path: "/api/debug", want: http.StatusOK},
+		{name: "unmatched", path: "/debug/missing", want: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.RemoveExtraSlash = true
+			r.Use(DevAccessWith(DevAccessConfig{}))
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			r.GET("/debug/pprof/:name", ok)
+			r.GET("/swagger/*any", ok)
+			r.GET("/api/:name", ok)
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.RemoteAddr = "8.8.8.8:1234"
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+// ErrorFormat 为engine或路由组指定App错误响应的格式
+func ErrorFormat(f ginApp.ErrorFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ginApp.SetErrorFormat(c, f)
+		c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/tracing"
+)
+
+// NewErrorLog 请求结束后记录 c.Errors 中的内部错误 如 App.ServerError 的原因
+// 公开错误(gin.ErrorTypePublic)已返回给调用方 不再记录
+func NewErrorLog(logFn func(msg string, keysAndVals ...zap.Field)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		errArr := c.Errors.ByType(gin.ErrorTypePrivate)
+		if len(errArr) == 0 {
+			return
+		}
+		app := ginApp.GetApp(c)
+		for _, err := range errArr {
+			keysAndValues := []zap.Field{
+				zap.Error(err.Err),
+				zap.String("reqID", app.GetReqID()),
+				zap.String("bdk.http.method", c.Request.Method),
+				zap.String("bdk.http.path", c.Request.URL.Path),
+			}
+			if err.Meta != nil {
+				keysAndValues = append(keysAndValues, zap.Any("meta", err.Meta))
+			}
+			keysAndValues = append(keysAndValues, tracing.ZapFields(c.Request.Context())...)
+			logFn("bdk.gin.error", keysAndValues...)
+		}
+	}
+}
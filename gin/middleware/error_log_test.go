@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/real-web-world/bdk/errs"
+	"github.com/real-web-world/bdk/fastcurd"
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+func TestErrorLog(t *testing.T) {
+	cases := []struct {
+		name     string
+		handler  func(app ginApp.App)
+		wantLogs int
+		leak     string // 不应出现在响应中的内容
+	}{
+		{name: "server error logged", handler: func(app ginApp.App) {
+			app.ServerError(errors.New("db down"))
+		}, wantLogs: 1, leak: "db down"},
+		{name: "meta not returned", handler: func(app ginApp.App) {
+			app.Error(errs.Wrap(errors.New("conflict"), fastcurd.CodeBadReq, "").
+				WithMeta("sql", "select secret").WithData(map[string]any{"field": "name"}))
+		}, wantLogs: 1, leak: "select secret"},
+		{name: "biz error without cause", handler: func(app ginApp.App) {
+			app.Error(errs.New(fastcurd.CodeBadReq, ""))
+		}},
+		{name: "success", handler: func(app ginApp.App) { app.Success() }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			r := gin.New()
+			r.Use(RequestID, NewErrorLog(zap.New(core).Info))
+			r.GET("/", func(c *gin.Context) { tc.handler(ginApp.GetApp(c)) })
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if logs.Len() != tc.wantLogs {
+				t.Fatalf("logs = %d, want %d", logs.Len(), tc.wantLogs)
+			}
+			if tc.leak != "" && strings.Contains(w.Body.String(), tc.leak) {
+				t.Fatalf("response leaks %q: %s", tc.leak, w.Body.String())
+			}
+			for _, entry := range logs.All() {
+				if entry.ContextMap()["reqID"] == "" {
+					t.Fatal("log without reqID")
+				}
+			}
+		})
+	}
+}
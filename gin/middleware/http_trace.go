@@ -3,6 +3,8 @@ package middleware
 import (
 	"bytes"
 	"io"
+	"mime"
+	"net/url"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -11,6 +13,7 @@ import (
 	"github.com/real-web-world/bdk"
 	"github.com/real-web-world/bdk/fastcurd"
 	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/tracing"
 )
 
 const (
@@ -20,7 +23,9 @@ const (
 )
 
 const (
-	ContentTypeJson = "application/json"
+	ContentTypeJson      = "application/json"
+	ContentTypeForm      = "application/x-www-form-urlencoded"
+	ContentTypeMultipart = "multipart/form-data"
 )
 const (
 	shouldTraceNone  = 0
@@ -28,7 +33,50 @@ const (
 	shouldTraceFalse = 2
 )
 const (
-	KeyResp = "resp"
+	KeyResp = ginApp.KeyResp
+)
+
+type (
+	HttpTraceLogFn  func(msg string, keysAndVals ...zap.Field)
+	HttpTraceConfig struct {
+		LogFn HttpTraceLogFn
+		// DefaultTrace 为false时只记录使用了 Trace 的路由
+		DefaultTrace bool
+		// MaxBodySize 请求体与响应体最多记录的字节数 为0时使用 MaxTraceSize
+		MaxBodySize int
+		// CaptureResp 记录全部响应体 为false时只记录使用了 SaveResp 的路由
+		CaptureResp bool
+		// RedactPaths 脱敏的json路径 为nil时使用 DefaultRedactPaths 语法见 Redactor
+		RedactPaths []string
+		// RouteBodySizes 按路由覆盖 MaxBodySize key为 c.FullPath() 或 "METHOD c.FullPath()" 为0时不记录
+		RouteBodySizes map[string]int
+		// Sampler 不为nil时 未使用 Trace/NotTrace 的路由由采样决定是否记录 DefaultTrace 不再生效
+		Sampler *TraceSampler
+	}
+	traceBody struct {
+		io.Reader
+		io.Closer
+	}
+	// errReader 预读结束后读取剩余请求体
+	errReader struct {
+		err error
+		r   io.Reader
+	}
+	traceRespWriter struct {
+		gin.ResponseWriter
+		body  bytes.Buffer
+		limit int
+	}
+	multipartSummary struct {
+		Fields map[string][]string `json:"fields,omitempty"`
+		Files  []multipartFile     `json:"files,omitempty"`
+	}
+	multipartFile struct {
+		Field       string `json:"field"`
+		Filename    string `json:"filename"`
+		Size        int64  `json:"size"`
+		ContentType string `json:"contentType,omitempty"`
+	}
 )
 
 func NewHttpTrace(logFn func(msg string, keysAndVals ...zap.Field)) func(c *gin.Context) {
@@ -38,59 +86,191 @@ func NewHttpTraceWithDefaultNotTrace(logFn func(msg string, keysAndVals ...zap.F
 	return NewHttpTraceWithDefaultTraceParam(logFn, false)
 }
 func NewHttpTraceWithDefaultTraceParam(logFn func(msg string, keysAndVals ...zap.Field), isDefaultTrace bool) func(c *gin.Context) {
+	return NewHttpTraceWithConfig(HttpTraceConfig{
+		LogFn:        logFn,
+		DefaultTrace: isDefaultTrace,
+	})
+}
+
+// NewHttpTraceWithConfig 请求体在处理函数之前预读 MaxBodySize 字节后放回 处理函数仍可完整读取
+// 响应体通过包装 ResponseWriter 捕获 json 请求体 表单 查询参数与响应体均按 RedactPaths 脱敏
+func NewHttpTraceWithConfig(cfg HttpTraceConfig) func(c *gin.Context) {
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = MaxTraceSize
+	}
+	if cfg.RedactPaths == nil {
+		cfg.RedactPaths = DefaultRedactPaths
+	}
+	redactor := NewRedactor(cfg.RedactPaths...)
 	return func(c *gin.Context) {
+		limit := cfg.MaxBodySize
+		if routeSize, _, ok := matchRoute(c, cfg.RouteBodySizes); ok {
+			limit = routeSize
+		}
+		peekLimit := limit
+		if cfg.Sampler != nil {
+			peekLimit = cfg.Sampler.peekSize(limit)
+		}
+		contentType := requestMediaType(c)
+		reqBody, reqTruncated := peekRequestBody(c, contentType, peekLimit)
+		// SaveResp 通常注册在路由上 执行到此时尚未设置 因此总是捕获 记录时再判断
+		respWriter := &traceRespWriter{ResponseWriter: c.Writer, limit: peekLimit}
+		c.Writer = respWriter
 		c.Next()
+		c.Writer = respWriter.ResponseWriter
 		statusCode := c.Writer.Status()
 		isShouldTraceVal := isShouldTrace(c)
-		if (isDefaultTrace && isShouldTraceVal == shouldTraceFalse) ||
-			(!isDefaultTrace && isShouldTraceVal != shouldTraceTrue) ||
-			bdk.IsSkipLogReq(c.Request, statusCode) {
+		full := false
+		switch {
+		case isShouldTraceVal == shouldTraceFalse || bdk.IsSkipLogReq(c.Request, statusCode):
+			return
+		case isShouldTraceVal == shouldTraceTrue:
+		case cfg.Sampler != nil:
+			decision := cfg.Sampler.decide(c, statusCode)
+			if decision == traceSkip {
+				return
+			}
+			full = decision == traceFull
+		case !cfg.DefaultTrace:
 			return
 		}
-		app := ginApp.GetApp(c)
-		reqID := app.GetReqID()
-		reqTime := app.GetProcBeginTime()
-		postData := ""
-		var resp *fastcurd.RetJSON
-		contentType := strings.Split(bdk.GetContentType(c.Request), ";")[0]
-		switch contentType {
-		case ContentTypeJson:
-			bodyBts, _ := io.ReadAll(c.Request.Body)
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBts))
-			postData = bdk.Bytes2Str(bodyBts)
-		}
-		procTime := app.GetProcTime()
-		if isShouldSaveResp(c) {
-			resp = GetCtxRespVal(c)
-		}
-		if len(postData) > MaxTraceSize {
-			postData = postData[:MaxTraceSize]
+		respBody, respTruncated := respWriter.body.Bytes(), respWriter.Size() > respWriter.body.Len()
+		if !full {
+			reqBody, reqTruncated = truncateTraceBody(reqBody, reqTruncated, limit)
+			respBody, respTruncated = truncateTraceBody(respBody, respTruncated, limit)
 		}
+		app := ginApp.GetApp(c)
 		keysAndValues := []zap.Field{
-			zap.String("bdk.http.url", c.Request.RequestURI),
+			zap.String("bdk.http.url", redactor.RedactURL(c.Request.RequestURI)),
 			zap.String("bdk.http.clientIP", c.ClientIP()),
 			zap.Int("bdk.http.statusCode", statusCode),
-			zap.String("bdk.gin.reqID", reqID),
-			zap.Duration("bdk.gin.procTime", procTime),
-			zap.Time("bdk.gin.reqTime", reqTime),
+			zap.String("bdk.gin.reqID", app.GetReqID()),
+			zap.Duration("bdk.gin.procTime", app.GetProcTime()),
+			zap.Time("bdk.gin.reqTime", app.GetProcBeginTime()),
 		}
-		if resp != nil {
-			keysAndValues = append(keysAndValues, zap.Any("bdk.http.resp", resp))
+		keysAndValues = append(keysAndValues, tracing.ZapFields(c.Request.Context())...)
+		switch {
+		case contentType == ContentTypeMultipart:
+			if summary := summarizeMultipart(c, redactor); summary != nil {
+				keysAndValues = append(keysAndValues, zap.Any("bdk.http.multipart", summary))
+			}
+		case contentType == ContentTypeForm && len(reqBody) > 0:
+			if values, err := url.ParseQuery(bdk.Bytes2Str(reqBody)); err == nil {
+				keysAndValues = append(keysAndValues, zap.Any("bdk.http.form", redactor.RedactValues(values)))
+			}
+		case len(reqBody) > 0:
+			keysAndValues = append(keysAndValues,
+				traceBodyField("bdk.gin.postData", reqBody, reqTruncated, redactor))
 		}
-		if postData != "" {
-			keysAndValues = append(keysAndValues, zap.String("bdk.gin.postData", postData))
+		if (full || cfg.CaptureResp || isShouldSaveResp(c)) && len(respBody) > 0 {
+			keysAndValues = append(keysAndValues, traceBodyField("bdk.http.resp", respBody, respTruncated, redactor))
 		}
-		logFn("bdk.httpTrace", keysAndValues...)
+		cfg.LogFn("bdk.httpTrace", keysAndValues...)
 	}
 }
-func GetCtxRespVal(c *gin.Context) *fastcurd.RetJSON {
-	if resp, ok := c.Get(KeyResp); ok {
-		return resp.(*fastcurd.RetJSON)
+
+func requestMediaType(c *gin.Context) string {
+	mediaType, _, err := mime.ParseMediaType(bdk.GetContentType(c.Request))
+	if err != nil {
+		return strings.TrimSpace(strings.Split(bdk.GetContentType(c.Request), ";")[0])
+	}
+	return mediaType
+}
+
+// peekRequestBody 读取前 limit 字节后与剩余部分拼接放回 multipart 与二进制内容不预读
+func peekRequestBody(c *gin.Context, contentType string, limit int) ([]byte, bool) {
+	body := c.Request.Body
+	if body == nil || limit <= 0 || !isTextContentType(contentType) {
+		return nil, false
+	}
+	buf := make([]byte, limit+1)
+	n, err := io.ReadFull(body, buf)
+	buf = buf[:n]
+	c.Request.Body = traceBody{
+		Reader: io.MultiReader(bytes.NewReader(buf), &errReader{err: err, r: body}),
+		Closer: body,
+	}
+	if n > limit {
+		return buf[:limit], true
 	}
-	return nil
+	return buf, false
+}
+func isTextContentType(contentType string) bool {
+	return contentType == ContentTypeJson || contentType == ContentTypeForm ||
+		strings.HasPrefix(contentType, "text/") || strings.HasSuffix(contentType, "+json") ||
+		strings.HasSuffix(contentType, "/xml")
+}
+
+func truncateTraceBody(bts []byte, truncated bool, limit int) ([]byte, bool) {
+	if len(bts) > limit {
+		return bts[:max(limit, 0)], true
+	}
+	return bts, truncated
+}
+
+// traceBodyField 合法的json脱敏后按结构记录 其余按字段名替换后记录为字符串
+func traceBodyField(key string, bts []byte, truncated bool, redactor *Redactor) zap.Field {
+	if !truncated {
+		if data, ok := redactor.RedactJSON(bts); ok {
+			return zap.Any(key, data)
+		}
+	}
+	return zap.String(key, redactor.RedactRaw(string(bts)))
+}
+
+// summarizeMultipart 只记录处理函数已解析的表单 文件仅记录元信息
+func summarizeMultipart(c *gin.Context, redactor *Redactor) *multipartSummary {
+	form := c.Request.MultipartForm
+	if form == nil {
+		return nil
+	}
+	summary := &multipartSummary{
+		Fields: redactor.RedactValues(form.Value),
+	}
+	for field, files := range form.File {
+		for _, file := range files {
+			summary.Files = append(summary.Files, multipartFile{
+				Field:       field,
+				Filename:    file.Filename,
+				Size:        file.Size,
+				ContentType: file.Header.Get(ginApp.HeaderContentType),
+			})
+		}
+	}
+	return summary
+}
+
+func (w *traceRespWriter) Write(bts []byte) (int, error) {
+	w.capture(bts)
+	return w.ResponseWriter.Write(bts)
+}
+func (w *traceRespWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+func (w *traceRespWriter) capture(bts []byte) {
+	if remain := w.limit - w.body.Len(); remain > 0 {
+		w.body.Write(bts[:min(len(bts), remain)])
+	}
+}
+
+// Read 预读时已到达结尾则直接返回EOF 预读出错时返回该错误(如超过 BodyLimit)
+func (r *errReader) Read(p []byte) (int, error) {
+	switch r.err {
+	case nil:
+		return r.r.Read(p)
+	case io.EOF, io.ErrUnexpectedEOF:
+		return 0, io.EOF
+	default:
+		return 0, r.err
+	}
+}
+
+func GetCtxRespVal(c *gin.Context) *fastcurd.RetJSON {
+	return ginApp.GetCtxRespVal(c)
 }
 func SetCtxRespVal(c *gin.Context, json *fastcurd.RetJSON) {
-	c.Set(KeyResp, json)
+	ginApp.SetCtxRespVal(c, json)
 }
 func isShouldSaveResp(c *gin.Context) bool {
 	b, ok := c.Get(KeySaveResp)
@@ -124,6 +304,8 @@ func setNotTrace(c *gin.Context) {
 func setShouldTraceVal(c *gin.Context, val int) {
 	c.Set(KeyShouldTrace, val)
 }
+
+// SaveResp 记录该路由的响应体
 func SaveResp(c *gin.Context) {
 	setSaveResp(c)
 	c.Next()
@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk"
+	"github.com/real-web-world/bdk/errs"
+	"github.com/real-web-world/bdk/fastcurd"
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/i18n"
+	"github.com/real-web-world/bdk/idempotency"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	MaxIdempotencyKeyLen      = 255
+	idempotencyPollInterval   = 50 * time.Millisecond
+	idempotencyAnonymousScope = "anonymous"
+)
+
+// DefaultIdempotencyMaxBodySize 计算请求指纹时最多读取的请求体大小
+const DefaultIdempotencyMaxBodySize = 1 << 20
+
+var (
+	// idempotencySkipHeaders 每次请求各不相同的响应头 不随记录保存
+	// 保存的是未压缩的响应体 Content-Encoding 由重放时的 Compress 重新协商
+	idempotencySkipHeaders = []string{
+		"Date", HeaderContentLength, HeaderContentEncoding, "Set-Cookie",
+		bdk.HeadReqID, ginApp.HeaderServerTiming,
+		HeaderRateLimitLimit, HeaderRateLimitRemaining, HeaderRateLimitReset, HeaderRateLimitPolicy,
+	}
+)
+
+type (
+	IdempotencyConfig struct {
+		Name  string // 多个中间件共用Store时用于区分key
+		Store idempotency.Store
+		// TTL 记录保留时间 默认 idempotency.DefaultTTL
+		TTL time.Duration
+		// LockTTL 处理中记录的过期时间 默认 idempotency.DefaultLockTTL
+		// 持有者崩溃后该时间内相同key响应409 应大于最长的处理时间
+		LockTTL time.Duration
+		// MaxBodySize 请求体超过该值时响应413 默认 DefaultIdempotencyMaxBodySize
+		MaxBodySize int64
+		// Methods 需要处理的方法 默认 POST PUT PATCH
+		Methods []string
+		// Required 为true时上述方法缺少 Idempotency-Key 响应 App.BadReq
+		Required bool
+		// WaitTimeout 相同key的请求正在处理时的最长等待时间 为0时立即响应409
+		WaitTimeout time.Duration
+	}
+	captureWriter struct {
+		gin.ResponseWriter
+		body bytes.Buffer
+	}
+)
+
+// Idempotency 按 Idempotency-Key + 调用方 + 请求指纹 保存首次响应并在重复请求时重放
+// key相同但请求内容不同时响应422 首次请求处理中时响应409
+// 首次请求响应5xx或业务码为服务器错误时不保存 允许重试
+// 文中的状态码均为 HonestStatus 下的状态码 兼容模式下为200 通过业务码区分
+func Idempotency(cfg IdempotencyConfig) gin.HandlerFunc {
+	if cfg.Store == nil {
+		cfg.Store = idempotency.NewMemoryStore()
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = idempotency.DefaultTTL
+	}
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = idempotency.DefaultLockTTL
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = DefaultIdempotencyMaxBodySize
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+	}
+	return func(c *gin.Context) {
+		if !slices.Contains(cfg.Methods, c.Request.Method) {
+			c.Next()
+			return
+		}
+		app := ginApp.GetApp(c)
+		idemKey := c.GetHeader(HeaderIdempotencyKey)
+		if idemKey == "" {
+			if cfg.Required {
+				app.Error(errs.New(fastcurd.CodeBadReq, i18n.MsgIdempotencyKeyRequired).
+					WithStatus(http.StatusBadRequest))
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(idemKey) > MaxIdempotencyKeyLen {
+			app.BadReq()
+			return
+		}
+		fingerprint, err := requestFingerprint(c.Request, cfg.MaxBodySize)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				app.Error(err)
+				return
+			}
+			app.BadReq()
+			return
+		}
+		scope := idempotencyAnonymousScope
+		if p := app.GetPrincipal(); p != nil {
+			scope = p.Method + ":" + p.ID
+		}
+		key := cfg.Name + "|" + scope + "|" + idemKey
+		ctx := c.Request.Context()
+		rec, acquired, err := cfg.Store.Lock(ctx, key, fingerprint, cfg.LockTTL)
+		deadline := time.Now().Add(cfg.WaitTimeout)
+		for err == nil && !acquired && !rec.Completed && rec.Fingerprint == fingerprint &&
+			time.Now().Before(deadline) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(idempotencyPollInterval):
+			}
+			rec, acquired, err = cfg.Store.Lock(ctx, key, fingerprint, cfg.LockTTL)
+		}
+		switch {
+		case err != nil:
+			app.ServerError(err)
+		case acquired:
+			handleIdempotentFirst(c, cfg, key, fingerprint)
+		case rec.Fingerprint != fingerprint:
+			app.Error(errs.New(fastcurd.CodeBadReq, i18n.MsgIdempotencyKeyMismatch).
+				WithStatus(http.StatusUnprocessableEntity))
+		case !rec.Completed:
+			app.Error(errs.New(fastcurd.CodeBadReq, i18n.MsgIdempotencyInProgress).
+				WithStatus(http.StatusConflict))
+		default:
+			replayIdempotent(c, rec)
+		}
+	}
+}
+
+func handleIdempotentFirst(c *gin.Context, cfg IdempotencyConfig, key, fingerprint string) {
+	ctx := c.Request.Context()
+	saved := false
+	defer func() {
+		// 处理失败或panic时释放 允许客户端重试
+		if !saved {
+			_ = cfg.Store.Release(ctx, key)
+		}
+	}()
+	w := &captureWriter{ResponseWriter: c.Writer}
+	c.Writer = w
+	c.Next()
+	c.Writer = w.ResponseWriter
+	status := w.Status()
+	if status >= http.StatusInternalServerError || isServerErrorResp(c) {
+		return
+	}
+	header := w.Header().Clone()
+	for _, name := range idempotencySkipHeaders {
+		header.Del(name)
+	}
+	rec := &idempotency.Record{
+		Fingerprint: fingerprint,
+		Completed:   true,
+		Status:      status,
+		Header:      header,
+		Body:        w.body.Bytes(),
+		CreatedAt:   time.Now(),
+	}
+	if err := cfg.Store.Save(ctx, key, rec, cfg.TTL); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypePrivate)
+		return
+	}
+	saved = true
+}
+func replayIdempotent(c *gin.Context, rec *idempotency.Record) {
+	header := c.Writer.Header()
+	for name, values := range rec.Header {
+		// 外层中间件(如 Compress CORS)已设置的 Vary 合并 避免重复
+		if name == HeaderVary {
+			for _, value := range values {
+				if !slices.Contains(header.Values(HeaderVary), value) {
+					header.Add(HeaderVary, value)
+				}
+			}
+			continue
+		}
+		header[name] = slices.Clone(values)
+	}
+	header.Set(HeaderIdempotentReplayed, "true")
+	c.Status(rec.Status)
+	_, _ = c.Writer.Write(rec.Body)
+	c.Abort()
+}
+
+// isServerErrorResp Legacy 策略下服务器错误的http状态码为200 需按业务码判断
+func isServerErrorResp(c *gin.Context) bool {
+	resp := ginApp.GetCtxRespVal(c)
+	return resp != nil && errs.HTTPStatus(resp.Code) >= http.StatusInternalServerError
+}
+
+// requestFingerprint 方法 路径 查询参数 与请求体的摘要 读取后恢复请求体
+// 请求体超过 maxSize 时返回 *http.MaxBytesError
+func requestFingerprint(r *http.Request, maxSize int64) (string, error) {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+"\n"+r.URL.Path+"\n"+r.URL.RawQuery+"\n")
+	if r.Body != nil && r.Body != http.NoBody {
+		bts, err := io.ReadAll(io.LimitReader(r.Body, maxSize+1))
+		if err != nil {
+			return "", err
+		}
+		if int64(len(bts)) > maxSize {
+			return "", &http.MaxBytesError{Limit: maxSize}
+		}
+		r.Body = io.NopCloser(bytes.NewReader(bts))
+		h.Write(bts)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (w *captureWriter) Write(bts []byte) (int, error) {
+	w.body.Write(bts)
+	return w.ResponseWriter.Write(bts)
+}
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
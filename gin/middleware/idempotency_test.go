@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/real-web-world/bdk/fastcurd"
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/json"
+)
+
+func TestIdempotency(t *testing.T) {
+	cases := []struct {
+		name     string
+		handler  func(app ginApp.App)
+		second   string // 第二次请求的请求体
+		maxBody  int64
+		legacy   bool
+		wantRuns int
+		wantCode int
+		replayed bool
+	}{
+		{
+			name:     "replay success",
+			handler:  func(app ginApp.App) { app.Data("ok") },
+			second:   `{"a":1}`,
+			wantRuns: 1, wantCode: http.StatusOK, replayed: true,
+		},
+		{
+			name:     "body mismatch",
+			handler:  func(app ginApp.App) { app.Data("ok") },
+			second:   `{"a":2}`,
+			wantRuns: 1, wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "legacy server error not saved",
+			handler:  func(app ginApp.App) { app.ServerError(errors.New("db down")) },
+			second:   `{"a":1}`,
+			legacy:   true,
+			wantRuns: 2, wantCode: http.StatusOK,
+		},
+		{
+			name:     "body too large",
+			handler:  func(app ginApp.App) { app.Data("ok") },
+			second:   `{"a":1}`,
+			maxBody:  3,
+			wantRuns: 0, wantCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runs := 0
+			r := gin.New()
+			if !tc.legacy {
+				r.Use(HonestStatus)
+			}
+			r.POST("/", Idempotency(IdempotencyConfig{MaxBodySize: tc.maxBody}), func(c *gin.Context) {
+				runs++
+				tc.handler(ginApp.GetApp(c))
+			})
+			var w *httptest.ResponseRecorder
+			for _, body := range []string{`{"a":1}`, tc.second} {
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+				req.Header.Set(HeaderIdempotencyKey, "key")
+				w = httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+			}
+			if runs != tc.wantRuns {
+				t.Fatalf("runs = %d, want %d", runs, tc.wantRuns)
+			}
+			if w.Code != tc.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tc.wantCode)
+			}
+			if replayed := w.Header().Get(HeaderIdempotentReplayed) == "true"; replayed != tc.replayed {
+				t.Fatalf("replayed = %v, want %v", replayed, tc.replayed)
+			}
+		})
+	}
+}
+
+// TestIdempotencyCompress 保存未压缩的响应体 重放时由 Compress 重新压缩
+func TestIdempotencyCompress(t *testing.T) {
+	payload := strings.Repeat("a", 4096)
+	cases := []struct {
+		name         string
+		accept       string
+		wantEncoding string
+	}{
+		{name: "gzip", accept: EncodingGzip, wantEncoding: EncodingGzip},
+		{name: "identity", accept: ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Compress(CompressConfig{}), Idempotency(IdempotencyConfig{}))
+			r.POST("/", func(c *gin.Context) {
+				ginApp.GetApp(c).Data(payload)
+			})
+			for i := range 2 {
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`))
+				req.Header.Set(HeaderIdempotencyKey, "key")
+				// 首次请求总是gzip 重放时按本次的 Accept-Encoding
+				accept := EncodingGzip
+				if i == 1 {
+					accept = tc.accept
+				}
+				req.Header.Set(HeaderAcceptEncoding, accept)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				if i == 0 {
+					continue
+				}
+				if w.Header().Get(HeaderIdempotentReplayed) != "true" {
+					t.Fatal("not replayed")
+				}
+				if got := w.Header().Get(HeaderContentEncoding); got != tc.wantEncoding {
+					t.Fatalf("Content-Encoding = %q, want %q", got, tc.wantEncoding)
+				}
+				if got := w.Header().Values(HeaderVary); !slices.Equal(got, []string{HeaderAcceptEncoding, ginApp.HeaderAccept}) {
+					t.Fatalf("Vary = %v", got)
+				}
+				var body io.Reader = w.Body
+				if tc.wantEncoding == EncodingGzip {
+					gr, err := gzip.NewReader(w.Body)
+					if err != nil {
+						t.Fatal(err)
+					}
+					body = gr
+				}
+				resp := fastcurd.RetJSON{}
+				if err := json.NewDecoder(body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Data != payload {
+					t.Fatalf("replayed data length = %d", len(fmt.Sprint(resp.Data)))
+				}
+			}
+		})
+	}
+}
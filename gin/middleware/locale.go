@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+// Locale 协商调用方语言并写入上下文 同时设置 Content-Language 响应头
+func Locale(c *gin.Context) {
+	locale := ginApp.GetApp(c).GetLocale()
+	ginApp.SetLocale(c, locale)
+	c.Header(ginApp.HeaderContentLanguage, locale)
+	c.Next()
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/errs"
+	"github.com/real-web-world/bdk/fastcurd"
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/ratelimit"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+type (
+	RateLimitKeyFn  func(c *gin.Context) string
+	RateLimitConfig struct {
+		Name  string // 多个限流中间件共用Store时用于区分key
+		Store ratelimit.Store
+		Limit ratelimit.Limit
+		KeyFn RateLimitKeyFn // 默认 KeyByIP
+		// Routes 按路由覆盖限额 key为 c.FullPath() 或 "METHOD c.FullPath()"
+		Routes map[string]ratelimit.Limit
+	}
+)
+
+// RateLimit 超出限额时以 CodeRateLimitError 响应429 任何响应策略下均为429 并返回 RateLimit-* 与 Retry-After 头
+// Store出错时放行请求 错误记录到gin上下文
+func RateLimit(cfg RateLimitConfig) gin.HandlerFunc {
+	if cfg.Store == nil {
+		cfg.Store = ratelimit.NewMemoryStore()
+	}
+	if cfg.KeyFn == nil {
+		cfg.KeyFn = KeyByIP
+	}
+	return func(c *gin.Context) {
+		limit, routeKey := cfg.Limit, ""
+		if routeLimit, key, ok := matchRoute(c, cfg.Routes); ok {
+			limit, routeKey = routeLimit, key
+		}
+		if !limit.Valid() {
+			c.Next()
+			return
+		}
+		key := cfg.Name + "|" + cfg.KeyFn(c)
+		if routeKey != "" {
+			key += "|" + routeKey
+		}
+		res, err := cfg.Store.Allow(c.Request.Context(), key, limit)
+		if err != nil {
+			_ = c.Error(err)
+			c.Next()
+			return
+		}
+		setRateLimitHeader(c, limit, res)
+		if !res.Allowed {
+			c.Header(HeaderRetryAfter, strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			ginApp.GetApp(c).Error(errs.New(fastcurd.CodeRateLimitError, ""))
+			return
+		}
+		c.Next()
+	}
+}
+
+// KeyByIP 按直连地址 不读取可伪造的转发头 部署在代理之后时所有请求共享代理的限额 应改用 KeyByClientIP
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.RemoteIP()
+}
+
+// KeyByClientIP 按 c.ClientIP() 需先通过 engine.SetTrustedProxies 配置可信代理
+// gin默认信任所有代理 未配置时客户端可通过 X-Forwarded-For 伪造ip绕过限流
+func KeyByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser 按调用方id 未登录时退化为 KeyByIP
+func KeyByUser(c *gin.Context) string {
+	if caller := fastcurd.GetCaller(c.Request.Context()); caller != nil {
+		return "user:" + caller.GetCallerID()
+	}
+	return KeyByIP(c)
+}
+
+// KeyByRoute 同一路由的所有请求共享限额
+func KeyByRoute(c *gin.Context) string {
+	return "route:" + c.Request.Method + " " + c.FullPath()
+}
+
+// KeyCombine 组合多个key 如同一用户在同一路由上的限额
+func KeyCombine(fns ...RateLimitKeyFn) RateLimitKeyFn {
+	return func(c *gin.Context) string {
+		key := ""
+		for i, fn := range fns {
+			if i > 0 {
+				key += "|"
+			}
+			key += fn(c)
+		}
+		return key
+	}
+}
+
+// matchRoute 按 "METHOD c.FullPath()" 或 c.FullPath() 查找路由配置
+func matchRoute[T any](c *gin.Context, routes map[string]T) (T, string, bool) {
+	var zero T
+	if len(routes) == 0 {
+		return zero, "", false
+	}
+	fullPath := c.FullPath()
+	methodKey := c.Request.Method + " " + fullPath
+	if val, ok := routes[methodKey]; ok {
+		return val, methodKey, true
+	}
+	if val, ok := routes[fullPath]; ok {
+		return val, fullPath, true
+	}
+	return zero, "", false
+}
+func setRateLimitHeader(c *gin.Context, limit ratelimit.Limit, res ratelimit.Result) {
+	c.Header(HeaderRateLimitLimit, strconv.Itoa(res.Limit))
+	c.Header(HeaderRateLimitRemaining, strconv.Itoa(max(res.Remaining, 0)))
+	c.Header(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(res.Reset)))
+	c.Header(HeaderRateLimitPolicy, strconv.Itoa(limit.Rate)+";w="+strconv.Itoa(ceilSeconds(limit.Period)))
+}
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/fastcurd"
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/json"
+	"github.com/real-web-world/bdk/ratelimit"
+)
+
+func TestRateLimitKeyByIP(t *testing.T) {
+	cases := []struct {
+		name           string
+		keyFn          RateLimitKeyFn
+		trustedProxies []string // 为空时为gin默认的信任所有代理
+		wantSecond     int      // 伪造不同 X-Forwarded-For 的第二个请求
+	}{
+		{name: "remote ip ignores forwarded", keyFn: KeyByIP, wantSecond: http.StatusTooManyRequests},
+		{name: "client ip with trusted proxy", keyFn: KeyByClientIP, trustedProxies: []string{"10.0.0.0/8"}, wantSecond: http.StatusOK},
+		{name: "client ip from untrusted peer", keyFn: KeyByClientIP, trustedProxies: []string{"192.168.0.0/16"}, wantSecond: http.StatusTooManyRequests},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			if tc.trustedProxies != nil {
+				if err := r.SetTrustedProxies(tc.trustedProxies); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r.Use(HonestStatus, RateLimit(RateLimitConfig{Limit: ratelimit.PerHour(1), KeyFn: tc.keyFn}))
+			r.GET("/", func(c *gin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+			status := 0
+			for _, xff := range []string{"1.1.1.1", "2.2.2.2"} {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = "10.0.0.1:1234"
+				req.Header.Set("X-Forwarded-For", xff)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				status = w.Code
+			}
+			if status != tc.wantSecond {
+				t.Fatalf("second status = %d, want %d", status, tc.wantSecond)
+			}
+		})
+	}
+}
+
+func TestRateLimitStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy ginApp.ResponsePolicy
+	}{
+		{name: "legacy", policy: ginApp.LegacyResponsePolicy},
+		{name: "honest", policy: ginApp.HonestResponsePolicy},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(ResponsePolicy(tc.policy), RateLimit(RateLimitConfig{Limit: ratelimit.PerHour(1)}))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+			var w *httptest.ResponseRecorder
+			for range 2 {
+				w = httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+			if w.Code != http.StatusTooManyRequests {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+			}
+			if w.Header().Get(HeaderRetryAfter) == "" {
+				t.Fatal("missing Retry-After")
+			}
+			if w.Header().Get(HeaderRateLimitRemaining) != "0" {
+				t.Fatalf("RateLimit-Remaining = %q", w.Header().Get(HeaderRateLimitRemaining))
+			}
+			resp := fastcurd.RetJSON{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != fastcurd.CodeRateLimitError {
+				t.Fatalf("code = %d, want %d", resp.Code, fastcurd.CodeRateLimitError)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/rbac"
+)
+
+// RequirePermission 调用方需拥有全部权限 未登录响应 App.NoLogin 无权限响应 App.NoAuth
+// a为nil时使用 rbac.Default
+func RequirePermission(a *rbac.Authorizer, perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorize(c, a, func(app ginApp.App) bool {
+			return app.GetAuthorizer().Allowed(app.GetPrincipal(), perms...)
+		})
+	}
+}
+
+// RequireAnyPermission 调用方需拥有任一权限
+func RequireAnyPermission(a *rbac.Authorizer, perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorize(c, a, func(app ginApp.App) bool {
+			return app.GetAuthorizer().AllowedAny(app.GetPrincipal(), perms...)
+		})
+	}
+}
+
+// RequireRole 调用方需拥有任一角色
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorize(c, nil, func(app ginApp.App) bool {
+			return slices.ContainsFunc(roles, app.GetPrincipal().HasRole)
+		})
+	}
+}
+
+// Authorize 按策略中的路由声明检查权限 未声明的路由直接放行
+// 路由声明随策略热加载生效
+func Authorize(a *rbac.Authorizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actA := a
+		if actA == nil {
+			actA = rbac.Default()
+		}
+		perms, ok := actA.RoutePermissions(c.Request.Method, c.FullPath())
+		if !ok {
+			ginApp.SetAuthorizer(c, actA)
+			c.Next()
+			return
+		}
+		authorize(c, actA, func(app ginApp.App) bool {
+			return actA.Allowed(app.GetPrincipal(), perms...)
+		})
+	}
+}
+
+func authorize(c *gin.Context, a *rbac.Authorizer, allowed func(app ginApp.App) bool) {
+	if a != nil {
+		ginApp.SetAuthorizer(c, a)
+	}
+	app := ginApp.GetApp(c)
+	if !app.IsLogin() {
+		app.NoLogin()
+		return
+	}
+	if !allowed(app) {
+		app.NoAuth()
+		return
+	}
+	c.Next()
+}
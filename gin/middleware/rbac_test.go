@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/auth"
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/rbac"
+)
+
+func newTestAuthorizer(t *testing.T) *rbac.Authorizer {
+	t.Helper()
+	a, err := rbac.NewAuthorizer(&rbac.Policy{
+		Roles: map[string]rbac.Role{
+			"viewer": {Permissions: []string{"order:view"}},
+			"editor": {Permissions: []string{"order:edit"}, Inherits: []string{"viewer"}},
+		},
+		Routes: map[string][]string{
+			"POST /order/:id": {"order:edit"},
+			"/order/:id":      {"order:view"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// withPrincipal 模拟认证中间件 p为nil时视为未登录
+func withPrincipal(p *auth.Principal) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p != nil {
+			ginApp.SetPrincipal(c, p)
+		}
+		c.Next()
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	a := newTestAuthorizer(t)
+	cases := []struct {
+		name      string
+		principal *auth.Principal
+		handler   gin.HandlerFunc
+		want      int
+	}{
+		{name: "no login", handler: RequirePermission(a, "order:view"), want: http.StatusUnauthorized},
+		{
+			name:      "allowed",
+			principal: &auth.Principal{ID: "1", Roles: []string{"viewer"}},
+			handler:   RequirePermission(a, "order:view"),
+			want:      http.StatusOK,
+		},
+		{
+			name:      "inherited",
+			principal: &auth.Principal{ID: "1", Roles: []string{"editor"}},
+			handler:   RequirePermission(a, "order:view", "order:edit"),
+			want:      http.StatusOK,
+		},
+		{
+			name:      "missing one",
+			principal: &auth.Principal{ID: "1", Roles: []string{"viewer"}},
+			handler:   RequirePermission(a, "order:view", "order:edit"),
+			want:      http.StatusForbidden,
+		},
+		{
+			name:      "scope",
+			principal: &auth.Principal{ID: "1", Scopes: []string{"order:*"}},
+			handler:   RequirePermission(a, "order:edit"),
+			want:      http.StatusOK,
+		},
+		{
+			name:      "any",
+			principal: &auth.Principal{ID: "1", Roles: []string{"viewer"}},
+			handler:   RequireAnyPermission(a, "order:view", "order:edit"),
+			want:      http.StatusOK,
+		},
+		{
+			name:      "any missing",
+			principal: &auth.Principal{ID: "1", Roles: []string{"viewer"}},
+			handler:   RequireAnyPermission(a, "user:view"),
+			want:      http.StatusForbidden,
+		},
+		{
+			name:      "role",
+			principal: &auth.Principal{ID: "1", Roles: []string{"editor"}},
+			handler:   RequireRole("admin", "editor"),
+			want:      http.StatusOK,
+		},
+		{
+			name:      "role missing",
+			principal: &auth.Principal{ID: "1", Roles: []string{"viewer"}},
+			handler:   RequireRole("admin"),
+			want:      http.StatusForbidden,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(HonestStatus, withPrincipal(tc.principal), tc.handler)
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	a := newTestAuthorizer(t)
+	viewer := &auth.Principal{ID: "1", Roles: []string{"viewer"}}
+	editor := &auth.Principal{ID: "2", Roles: []string{"editor"}}
+	cases := []struct {
+		name      string
+		principal *auth.Principal
+		method    string
+		path      string
+		want      int
+	}{
+		{name: "undeclared no login", method: http.MethodGet, path: "/public", want: http.StatusOK},
+		{name: "declared no login", method: http.MethodGet, path: "/order/1", want: http.StatusUnauthorized},
+		{name: "route template", principal: viewer, method: http.MethodGet, path: "/order/1", want: http.StatusOK},
+		{name: "method declaration", principal: viewer, method: http.MethodPost, path: "/order/1", want: http.StatusForbidden},
+		{name: "method declaration allowed", principal: editor, method: http.MethodPost, path: "/order/1", want: http.StatusOK},
+		{name: "unmatched route", principal: viewer, method: http.MethodGet, path: "/missing", want: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(HonestStatus, withPrincipal(tc.principal), Authorize(a))
+			ok := func(c *gin.Context) {
+				if ginApp.GetApp(c).GetAuthorizer() != a {
+					t.Error("authorizer not set on context")
+				}
+				c.Status(http.StatusOK)
+			}
+			r.GET("/public", ok)
+			r.GET("/order/:id", ok)
+			r.POST("/order/:id", ok)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeReload(t *testing.T) {
+	a := newTestAuthorizer(t)
+	r := gin.New()
+	r.Use(HonestStatus, withPrincipal(&auth.Principal{ID: "1", Roles: []string{"viewer"}}), Authorize(a))
+	r.GET("/user/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	serve := func() int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/1", nil))
+		return w.Code
+	}
+	if got := serve(); got != http.StatusOK {
+		t.Fatalf("undeclared status = %d, want %d", got, http.StatusOK)
+	}
+	policy := *a.Policy()
+	policy.Routes = map[string][]string{"/user/:id": {"user:view"}}
+	if err := a.SetPolicy(&policy); err != nil {
+		t.Fatal(err)
+	}
+	if got := serve(); got != http.StatusForbidden {
+		t.Fatalf("declared status = %d, want %d", got, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/real-web-world/bdk"
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+const (
+	maxReqIDLen = 128
+)
+
+// RequestID 请求头中没有合法的 X-Request-ID 时生成uuid
+// 请求id写入gin上下文,请求上下文(供 bdk.ReqIDTransport 透传)以及响应头
+func RequestID(c *gin.Context) {
+	reqID := c.GetHeader(ginApp.HeaderReqID)
+	if !isValidReqID(reqID) {
+		reqID = uuid.NewString()
+		c.Request.Header.Set(ginApp.HeaderReqID, reqID)
+	}
+	c.Set(ginApp.KeyReqID, reqID)
+	c.Request = c.Request.WithContext(bdk.WithReqID(c.Request.Context(), reqID))
+	c.Header(ginApp.HeaderReqID, reqID)
+	c.Next()
+}
+
+// isValidReqID 防止日志注入 只接受可见ascii字符
+func isValidReqID(reqID string) bool {
+	if reqID == "" || len(reqID) > maxReqIDLen {
+		return false
+	}
+	for i := 0; i < len(reqID); i++ {
+		if reqID[i] <= ' ' || reqID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+// ResponsePolicy 为engine或路由组指定App响应的http状态码策略
+func ResponsePolicy(p ginApp.ResponsePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ginApp.SetResponsePolicy(c, p)
+		c.Next()
+	}
+}
+
+// HonestStatus 使用真实http状态码响应业务错误
+func HonestStatus(c *gin.Context) {
+	ginApp.SetResponsePolicy(c, ginApp.HonestResponsePolicy)
+	c.Next()
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+// Timing 记录请求处理的开始与结束时间
+// App响应时在 extra.procTime 中返回耗时 并通过 Server-Timing 响应头输出各段耗时
+func Timing(c *gin.Context) {
+	ginApp.SetProcBeginTime(c)
+	ginApp.AddRespHook(c, ginApp.RespHookTiming)
+	c.Next()
+	ginApp.SetProcEndTime(c)
+}
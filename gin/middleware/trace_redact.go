@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/real-web-world/bdk/json"
+)
+
+const (
+	RedactedVal = "***"
+)
+
+var (
+	// DefaultRedactPaths 任意层级的 password token phone
+	DefaultRedactPaths = []string{"..password", "..token", "..phone"}
+)
+
+type (
+	// Redactor 按json路径脱敏
+	// 路径以.分隔 * 匹配任意一级 前缀..表示任意层级(如 ..password) 可带 $ 前缀 数组元素透明展开
+	Redactor struct {
+		paths [][]string
+		names []string
+		rawRe *regexp.Regexp
+	}
+)
+
+func NewRedactor(paths ...string) *Redactor {
+	r := &Redactor{}
+	nameSet := make(map[string]struct{})
+	for _, path := range paths {
+		path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+		if path == "" {
+			continue
+		}
+		var segs []string
+		if rest, ok := strings.CutPrefix(path, ".."); ok {
+			segs = append(segs, "..")
+			path = rest
+		}
+		segs = append(segs, strings.Split(strings.TrimPrefix(path, "."), ".")...)
+		name := segs[len(segs)-1]
+		if name == "" {
+			continue
+		}
+		r.paths = append(r.paths, segs)
+		if _, ok := nameSet[name]; !ok && name != "*" {
+			nameSet[name] = struct{}{}
+			r.names = append(r.names, name)
+		}
+	}
+	if len(r.names) > 0 {
+		quoted := make([]string, 0, len(r.names))
+		for _, name := range r.names {
+			quoted = append(quoted, regexp.QuoteMeta(name))
+		}
+		r.rawRe = regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") +
+			`)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	}
+	return r
+}
+
+// RedactJSON 解析并脱敏 bts不是合法json时返回false
+func (r *Redactor) RedactJSON(bts []byte) (any, bool) {
+	dec := json.NewDecoder(bytes.NewReader(bts))
+	dec.UseNumber()
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		return nil, false
+	}
+	for _, path := range r.paths {
+		data = redactPath(data, path)
+	}
+	return data, true
+}
+
+// RedactRaw 无法解析的json(如被截断)按字段名替换
+func (r *Redactor) RedactRaw(str string) string {
+	if r.rawRe == nil {
+		return str
+	}
+	return r.rawRe.ReplaceAllString(str, `${1}"`+RedactedVal+`"`)
+}
+
+// RedactValues 表单与查询参数没有层级 按路径最后一段匹配
+func (r *Redactor) RedactValues(values url.Values) url.Values {
+	if len(r.names) == 0 || len(values) == 0 {
+		return values
+	}
+	redacted := make(url.Values, len(values))
+	for key, vals := range values {
+		if r.matchName(key) {
+			redacted[key] = []string{RedactedVal}
+			continue
+		}
+		redacted[key] = vals
+	}
+	return redacted
+}
+
+// RedactURL 脱敏查询参数
+func (r *Redactor) RedactURL(requestURI string) string {
+	path, rawQuery, ok := strings.Cut(requestURI, "?")
+	if !ok || len(r.names) == 0 {
+		return requestURI
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return path + "?" + r.RedactRaw(rawQuery)
+	}
+	return path + "?" + r.RedactValues(values).Encode()
+}
+func (r *Redactor) matchName(key string) bool {
+	for _, name := range r.names {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactPath(data any, path []string) any {
+	if len(path) == 0 {
+		return RedactedVal
+	}
+	switch val := data.(type) {
+	case []any:
+		for i, item := range val {
+			val[i] = redactPath(item, path)
+		}
+		return val
+	case map[string]any:
+		seg := path[0]
+		if seg == ".." {
+			// 当前层级匹配剩余路径 同时继续向下查找
+			val = redactPath(val, path[1:]).(map[string]any)
+			for key, item := range val {
+				val[key] = redactPath(item, path)
+			}
+			return val
+		}
+		for key, item := range val {
+			if seg == "*" || strings.EqualFold(seg, key) {
+				val[key] = redactPath(item, path[1:])
+			}
+		}
+		return val
+	default:
+		return data
+	}
+}
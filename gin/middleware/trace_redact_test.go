@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/real-web-world/bdk/json"
+)
+
+func TestRedactJSON(t *testing.T) {
+	cases := []struct {
+		name  string
+		paths []string
+		body  string
+		want  string
+	}{
+		{name: "any level", paths: DefaultRedactPaths, body: `{"password":"a","user":{"Token":"b","name":"c"}}`,
+			want: `{"password":"***","user":{"Token":"***","name":"c"}}`},
+		{name: "array elements", paths: []string{"..phone"}, body: `{"list":[{"phone":"1"},{"phone":2}]}`,
+			want: `{"list":[{"phone":"***"},{"phone":"***"}]}`},
+		{name: "exact path", paths: []string{"$.user.secret"}, body: `{"secret":"a","user":{"secret":"b"}}`,
+			want: `{"secret":"a","user":{"secret":"***"}}`},
+		{name: "wildcard", paths: []string{"*.key"}, body: `{"a":{"key":1},"b":{"key":2},"key":3}`,
+			want: `{"a":{"key":"***"},"b":{"key":"***"},"key":3}`},
+		{name: "object value", paths: []string{"auth"}, body: `{"auth":{"user":"a"}}`, want: `{"auth":"***"}`},
+		{name: "big number kept", paths: []string{"x"}, body: `{"id":1234567890123456789}`, want: `{"id":1234567890123456789}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, ok := NewRedactor(tc.paths...).RedactJSON([]byte(tc.body))
+			if !ok {
+				t.Fatal("RedactJSON() not ok")
+			}
+			bts, err := json.Marshal(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bts) != tc.want {
+				t.Fatalf("RedactJSON() = %s, want %s", bts, tc.want)
+			}
+		})
+	}
+	if _, ok := NewRedactor(DefaultRedactPaths...).RedactJSON([]byte(`{"password":`)); ok {
+		t.Fatal("RedactJSON() ok for invalid json")
+	}
+}
+
+func TestRedactRaw(t *testing.T) {
+	r := NewRedactor(DefaultRedactPaths...)
+	cases := []struct {
+		raw  string
+		want string
+	}{
+		{raw: `{"password": "a\"b", "name":"c"}`, want: `{"password": "***", "name":"c"}`},
+		{raw: `{"phone":13812345678,"token":"abc`, want: `{"phone":"***","token":"***"`},
+		{raw: `{"PASSWORD":"x"}`, want: `{"PASSWORD":"***"}`},
+		{raw: `{"name":"password"}`, want: `{"name":"password"}`},
+	}
+	for _, tc := range cases {
+		if got := r.RedactRaw(tc.raw); got != tc.want {
+			t.Errorf("RedactRaw(%s) = %s, want %s", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	r := NewRedactor(DefaultRedactPaths...)
+	cases := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "/login", want: "/login"},
+		{uri: "/login?token=abc&page=1", want: "/login?page=1&token=" + url.QueryEscape(RedactedVal)},
+		{uri: "/login?Password=abc", want: "/login?Password=" + url.QueryEscape(RedactedVal)},
+	}
+	for _, tc := range cases {
+		if got := r.RedactURL(tc.uri); got != tc.want {
+			t.Errorf("RedactURL(%s) = %s, want %s", tc.uri, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/fastcurd"
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+const (
+	// TraceControlPath 运行时调整采样的接口 相对于 RegisterTraceControl 传入的路由组
+	TraceControlPath = "/trace"
+	// DefaultTraceFullBodySize 强制追踪的请求记录的请求体与响应体大小
+	DefaultTraceFullBodySize = 64 << 10
+)
+
+type (
+	// TraceSamplingConfig 采样配置 可通过 TraceControlPath 在运行时修改
+	TraceSamplingConfig struct {
+		// Rate 采样率 0-1
+		Rate float64 `json:"rate" binding:"min=0,max=1"`
+		// OnError 出错的请求(http状态码>=400 业务码非0 或gin上下文有错误)总是记录
+		OnError bool `json:"onError"`
+		// SlowThresholdMs 处理时间超过该值的请求总是记录 为0时不启用
+		SlowThresholdMs int64 `json:"slowThresholdMs" binding:"min=0"`
+		// Users 强制完整记录这些调用方的请求 包含响应体 请求体上限为 FullBodySize
+		Users []string `json:"users"`
+		// ReqIDPrefixes 强制完整记录请求id有这些前缀的请求
+		ReqIDPrefixes []string `json:"reqIDPrefixes"`
+		// ForceUntil Users 与 ReqIDPrefixes 的过期时间 为空时不过期
+		ForceUntil *time.Time `json:"forceUntil,omitempty"`
+		// FullBodySize 为0时使用 DefaultTraceFullBodySize
+		FullBodySize int `json:"fullBodySize" binding:"min=0"`
+	}
+	// TraceSampler 并发安全 配置整体替换
+	TraceSampler struct {
+		cfg atomic.Pointer[TraceSamplingConfig]
+	}
+	traceDecision int
+)
+
+const (
+	traceSkip traceDecision = iota
+	traceSampled
+	traceFull
+)
+
+func NewTraceSampler(cfg TraceSamplingConfig) *TraceSampler {
+	s := &TraceSampler{}
+	s.SetConfig(cfg)
+	return s
+}
+func (s *TraceSampler) Config() TraceSamplingConfig {
+	return *s.cfg.Load()
+}
+func (s *TraceSampler) SetConfig(cfg TraceSamplingConfig) {
+	if cfg.FullBodySize <= 0 {
+		cfg.FullBodySize = DefaultTraceFullBodySize
+	}
+	s.cfg.Store(&cfg)
+}
+
+// forcing 是否配置了未过期的强制记录
+func (cfg *TraceSamplingConfig) forcing(now time.Time) bool {
+	return (len(cfg.Users) > 0 || len(cfg.ReqIDPrefixes) > 0) &&
+		(cfg.ForceUntil == nil || now.Before(*cfg.ForceUntil))
+}
+
+// peekSize 请求处理前无法得知调用方 有强制记录时按完整大小预读
+func (s *TraceSampler) peekSize(limit int) int {
+	cfg := s.cfg.Load()
+	if cfg.forcing(time.Now()) {
+		return max(limit, cfg.FullBodySize)
+	}
+	return limit
+}
+
+// decide 请求处理完成后决定是否记录
+func (s *TraceSampler) decide(c *gin.Context, statusCode int) traceDecision {
+	cfg := s.cfg.Load()
+	app := ginApp.GetApp(c)
+	if cfg.forcing(time.Now()) {
+		if reqID := app.GetReqID(); reqID != "" {
+			for _, prefix := range cfg.ReqIDPrefixes {
+				if strings.HasPrefix(reqID, prefix) {
+					return traceFull
+				}
+			}
+		}
+		if caller := fastcurd.GetCaller(c.Request.Context()); caller != nil {
+			for _, user := range cfg.Users {
+				if caller.GetCallerID() == user {
+					return traceFull
+				}
+			}
+		}
+	}
+	if cfg.OnError && isTraceError(c, statusCode) {
+		return traceSampled
+	}
+	if cfg.SlowThresholdMs > 0 && app.GetProcTime() >= time.Duration(cfg.SlowThresholdMs)*time.Millisecond {
+		return traceSampled
+	}
+	if cfg.Rate > 0 && rand.Float64() < cfg.Rate {
+		return traceSampled
+	}
+	return traceSkip
+}
+func isTraceError(c *gin.Context, statusCode int) bool {
+	if statusCode >= http.StatusBadRequest || len(c.Errors) > 0 {
+		return true
+	}
+	resp := ginApp.GetCtxRespVal(c)
+	return resp != nil && resp.Code != fastcurd.CodeOk
+}
+
+// RegisterTraceControl 在r下注册 GET/PUT TraceControlPath 查看与替换采样配置
+// guards 为接口的访问控制 为空时使用 DevAccessWith(DevAccessConfig{All: true}) 仅允许私有网络直连
+//
+//	RegisterTraceControl(r.Group(bdk.DebugApiPrefix), sampler) // /debug/trace
+func RegisterTraceControl(r gin.IRoutes, sampler *TraceSampler, guards ...gin.HandlerFunc) {
+	if len(guards) == 0 {
+		guards = []gin.HandlerFunc{DevAccessWith(DevAccessConfig{All: true})}
+	}
+	guards = slices.Clip(guards)
+	r.GET(TraceControlPath, append(guards, func(c *gin.Context) {
+		ginApp.GetApp(c).Data(sampler.Config())
+	})...)
+	r.PUT(TraceControlPath, append(guards, func(c *gin.Context) {
+		app := ginApp.GetApp(c)
+		cfg := TraceSamplingConfig{}
+		if err := c.ShouldBindJSON(&cfg); err != nil {
+			app.ValidError(err)
+			return
+		}
+		sampler.SetConfig(cfg)
+		app.Data(sampler.Config())
+	})...)
+}
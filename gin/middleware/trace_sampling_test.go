@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk"
+	"github.com/real-web-world/bdk/fastcurd"
+	ginApp "github.com/real-web-world/bdk/gin"
+)
+
+type traceCaller string
+
+func (c traceCaller) GetCallerID() string {
+	return string(c)
+}
+func (c traceCaller) GetCallerRoles() []string {
+	return nil
+}
+
+func TestTraceSamplerDecide(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	cases := []struct {
+		name   string
+		cfg    TraceSamplingConfig
+		reqID  string
+		caller string
+		status int
+		code   fastcurd.Code
+		want   traceDecision
+	}{
+		{name: "rate zero", status: http.StatusOK, want: traceSkip},
+		{name: "rate one", cfg: TraceSamplingConfig{Rate: 1}, status: http.StatusOK, want: traceSampled},
+		{name: "http error", cfg: TraceSamplingConfig{OnError: true}, status: http.StatusBadRequest, want: traceSampled},
+		{name: "biz error", cfg: TraceSamplingConfig{OnError: true}, status: http.StatusOK, code: fastcurd.CodeBadReq, want: traceSampled},
+		{name: "error ignored", status: http.StatusInternalServerError, want: traceSkip},
+		{name: "forced user", cfg: TraceSamplingConfig{Users: []string{"42"}}, caller: "42", status: http.StatusOK, want: traceFull},
+		{name: "forced req id", cfg: TraceSamplingConfig{ReqIDPrefixes: []string{"dbg-"}}, reqID: "dbg-1", status: http.StatusOK, want: traceFull},
+		{name: "force expired", cfg: TraceSamplingConfig{Users: []string{"42"}, ForceUntil: &expired}, caller: "42", status: http.StatusOK, want: traceSkip},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx := context.Background()
+			if tc.caller != "" {
+				ctx = fastcurd.WithCaller(ctx, traceCaller(tc.caller))
+			}
+			c.Request = httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+			if tc.reqID != "" {
+				c.Set(ginApp.KeyReqID, tc.reqID)
+			}
+			ginApp.SetCtxRespVal(c, &fastcurd.RetJSON{Code: tc.code})
+			if got := NewTraceSampler(tc.cfg).decide(c, tc.status); got != tc.want {
+				t.Fatalf("decide() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRegisterTraceControl(t *testing.T) {
+	denyAll := func(c *gin.Context) { c.AbortWithStatus(http.StatusForbidden) }
+	cases := []struct {
+		name       string
+		guards     []gin.HandlerFunc
+		method     string
+		remoteAddr string
+		body       string
+		wantStatus int
+		wantRate   float64
+	}{
+		{name: "private get", method: http.MethodGet, remoteAddr: "127.0.0.1:1234", wantStatus: http.StatusOK, wantRate: 0.1},
+		{name: "private put", method: http.MethodPut, remoteAddr: "10.0.0.1:1234", body: `{"rate":0.5}`, wantStatus: http.StatusOK, wantRate: 0.5},
+		{name: "public denied", method: http.MethodPut, remoteAddr: "203.0.113.1:1234", body: `{"rate":0.5}`, wantStatus: http.StatusNotFound, wantRate: 0.1},
+		{name: "custom guard", guards: []gin.HandlerFunc{denyAll}, method: http.MethodGet, remoteAddr: "127.0.0.1:1234", wantStatus: http.StatusForbidden, wantRate: 0.1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampler := NewTraceSampler(TraceSamplingConfig{Rate: 0.1})
+			r := gin.New()
+			RegisterTraceControl(r.Group(bdk.DebugApiPrefix), sampler, tc.guards...)
+			req := httptest.NewRequest(tc.method, bdk.DebugApiPrefix+TraceControlPath, strings.NewReader(tc.body))
+			req.RemoteAddr = tc.remoteAddr
+			req.Header.Set(ginApp.HeaderContentType, "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tc.wantStatus)
+			}
+			if got := sampler.Config().Rate; got != tc.wantRate {
+				t.Fatalf("rate = %v, want %v", got, tc.wantRate)
+			}
+		})
+	}
+}
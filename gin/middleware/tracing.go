@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/real-web-world/bdk/fastcurd"
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/tracing"
+)
+
+var defaultTracing = TracingWith()
+
+// Tracing 按 W3C traceparent 延续上游链路并创建server span 需注册在 RequestID 之后以关联请求id
+// span写入请求上下文 fastcurd 查询(配合 tracing.GormPlugin)与 tracing.Transport 的调用成为其子span
+func Tracing(c *gin.Context) {
+	defaultTracing(c)
+}
+
+// TracingWith 使用指定的 TracerProvider 与传播器 未指定时使用全局设置
+func TracingWith(opts ...tracing.Option) gin.HandlerFunc {
+	inst := tracing.NewInstrumentation(opts...)
+	return func(c *gin.Context) {
+		traceRequest(c, inst)
+	}
+}
+func traceRequest(c *gin.Context, inst tracing.Instrumentation) {
+	ctx := inst.Propagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	route := c.FullPath()
+	spanName := c.Request.Method
+	if route != "" {
+		spanName += " " + route
+	}
+	ctx, span := inst.Tracer().Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.URLPath(c.Request.URL.Path),
+			semconv.ClientAddress(c.ClientIP()),
+			semconv.UserAgentOriginal(c.Request.UserAgent()),
+		),
+	)
+	defer span.End()
+	if route != "" {
+		span.SetAttributes(semconv.HTTPRoute(route))
+	}
+	if reqID := ginApp.GetApp(c).GetReqID(); reqID != "" {
+		span.SetAttributes(tracing.AttrReqID.String(reqID))
+	}
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+	status := c.Writer.Status()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	for _, err := range c.Errors {
+		span.RecordError(err.Err)
+	}
+	// 兼容模式下服务器错误的http状态码为200 按业务码判断
+	if resp := ginApp.GetCtxRespVal(c); status >= http.StatusInternalServerError ||
+		(resp != nil && resp.Code == fastcurd.CodeServerError) {
+		span.SetStatus(codes.Error, http.StatusText(http.StatusInternalServerError))
+	}
+}
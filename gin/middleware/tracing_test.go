@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+
+	ginApp "github.com/real-web-world/bdk/gin"
+	"github.com/real-web-world/bdk/tracing"
+	"github.com/real-web-world/bdk/tracing/tracingtest"
+)
+
+func TestTracing(t *testing.T) {
+	const traceID = "0af7651916cd43dd8448eb211c80319c"
+	cases := []struct {
+		name        string
+		traceparent string
+		handler     func(app ginApp.App)
+		wantError   bool
+	}{
+		{name: "continue upstream", traceparent: "00-" + traceID + "-b7ad6b7169203331-01",
+			handler: func(app ginApp.App) { app.Success() }},
+		{name: "new root", handler: func(app ginApp.App) { app.Success() }},
+		{name: "legacy server error", handler: func(app ginApp.App) {
+			app.ServerError(errors.New("db down"))
+		}, wantError: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := tracingtest.New()
+			r := gin.New()
+			r.Use(RequestID, TracingWith(rec.Options()...))
+			var handlerTraceID string
+			r.GET("/items/:id", func(c *gin.Context) {
+				handlerTraceID = tracing.TraceID(c.Request.Context())
+				tc.handler(ginApp.GetApp(c))
+			})
+			req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+			spans := rec.Spans()
+			if len(spans) != 1 {
+				t.Fatalf("spans = %d", len(spans))
+			}
+			span := spans[0]
+			if span.Name != "GET /items/:id" {
+				t.Fatalf("span name = %q", span.Name)
+			}
+			if handlerTraceID != span.SpanContext.TraceID().String() {
+				t.Fatal("request ctx does not carry the server span")
+			}
+			if tc.traceparent != "" && span.SpanContext.TraceID().String() != traceID {
+				t.Fatal("upstream trace not continued")
+			}
+			if (span.Status.Code == codes.Error) != tc.wantError {
+				t.Fatalf("status = %v, want error %v", span.Status.Code, tc.wantError)
+			}
+			hasReqID := false
+			for _, kv := range span.Attributes {
+				hasReqID = hasReqID || (kv.Key == tracing.AttrReqID && kv.Value.AsString() != "")
+			}
+			if !hasReqID {
+				t.Fatal("req id attribute missing")
+			}
+		})
+	}
+}
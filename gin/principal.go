@@ -0,0 +1,55 @@
+package ginApp
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/auth"
+	"github.com/real-web-world/bdk/rbac"
+)
+
+const (
+	KeyPrincipal  = "bdk.principal"
+	KeyAuthorizer = "bdk.authorizer"
+)
+
+// SetPrincipal 写入gin上下文与请求上下文 请求上下文中同时作为 fastcurd.Caller
+func SetPrincipal(c *gin.Context, p *auth.Principal) {
+	c.Set(KeyPrincipal, p)
+	c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+}
+
+// GetPrincipal 未认证时返回nil
+func (app App) GetPrincipal() *auth.Principal {
+	if p, ok := app.C.Get(KeyPrincipal); ok {
+		if actP, ok := p.(*auth.Principal); ok {
+			return actP
+		}
+	}
+	return auth.FromContext(app.C.Request.Context())
+}
+func (app App) IsLogin() bool {
+	return app.GetPrincipal() != nil
+}
+
+// SetAuthorizer 指定当前请求使用的授权器 未指定时使用 rbac.Default
+func SetAuthorizer(c *gin.Context, a *rbac.Authorizer) {
+	c.Set(KeyAuthorizer, a)
+}
+func (app App) GetAuthorizer() *rbac.Authorizer {
+	if a, ok := app.C.Get(KeyAuthorizer); ok {
+		if actA, ok := a.(*rbac.Authorizer); ok {
+			return actA
+		}
+	}
+	return rbac.Default()
+}
+
+// GetPermissions 当前调用方的有效权限
+func (app App) GetPermissions() []string {
+	return app.GetAuthorizer().Permissions(app.GetPrincipal())
+}
+
+// HasPermission 当前调用方是否拥有全部权限
+func (app App) HasPermission(perms ...string) bool {
+	return app.GetAuthorizer().Allowed(app.GetPrincipal(), perms...)
+}
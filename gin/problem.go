@@ -0,0 +1,113 @@
+package ginApp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/errs"
+	"github.com/real-web-world/bdk/fastcurd"
+	"github.com/real-web-world/bdk/json"
+	"github.com/real-web-world/bdk/valid"
+)
+
+const (
+	ContentTypeProblemJSON = "application/problem+json"
+	KeyErrorFormat         = "bdk.errorFormat"
+)
+
+// ErrorFormat
+const (
+	// ErrorFormatEnvelope 错误使用 RetJSON 响应
+	ErrorFormatEnvelope ErrorFormat = iota
+	// ErrorFormatProblem 错误使用 RFC 7807 application/problem+json 响应
+	ErrorFormatProblem
+	// ErrorFormatNegotiate Accept 包含 application/problem+json 时使用 problem 否则使用 RetJSON
+	ErrorFormatNegotiate
+)
+
+var (
+	defaultErrorFormat = ErrorFormatEnvelope
+	// ProblemTypeBaseURI problem type 的前缀 为空时 type 为 about:blank
+	ProblemTypeBaseURI = ""
+)
+
+type (
+	ErrorFormat int
+	// Problem RFC 7807 错误响应 Code 与 Errors 为扩展字段
+	Problem struct {
+		Type     string             `json:"type" example:"about:blank"`
+		Title    string             `json:"title" example:"Unprocessable Entity"`
+		Status   int                `json:"status" example:"422"`
+		Detail   string             `json:"detail,omitempty"`
+		Instance string             `json:"instance,omitempty"`
+		Code     fastcurd.Code      `json:"code" example:"4"`
+		Errors   []valid.FieldError `json:"errors,omitempty"`
+		Data     any                `json:"data,omitempty"`
+	}
+)
+
+func SetDefaultErrorFormat(f ErrorFormat) {
+	defaultErrorFormat = f
+}
+func SetErrorFormat(c *gin.Context, f ErrorFormat) {
+	c.Set(KeyErrorFormat, f)
+}
+func (app App) GetErrorFormat() ErrorFormat {
+	if f, ok := app.C.Get(KeyErrorFormat); ok {
+		if format, ok := f.(ErrorFormat); ok {
+			return format
+		}
+	}
+	return defaultErrorFormat
+}
+
+// NewProblem 将错误响应转换为 problem status为2xx时按业务码映射
+func (app App) NewProblem(status int, retJson fastcurd.RetJSON) Problem {
+	if status < http.StatusBadRequest {
+		status = errs.HTTPStatus(retJson.Code)
+	}
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   retJson.Msg,
+		Instance: app.GetReqID(),
+		Code:     retJson.Code,
+	}
+	if ProblemTypeBaseURI != "" {
+		problem.Type = strings.TrimSuffix(ProblemTypeBaseURI, "/") + "/" + strconv.Itoa(int(retJson.Code))
+	}
+	if fieldErrs, ok := retJson.Data.([]valid.FieldError); ok {
+		problem.Errors = fieldErrs
+	} else {
+		problem.Data = retJson.Data
+	}
+	return problem
+}
+
+// shouldWriteProblem 注册为非错误状态的业务码(如 CodeNoChange)保持 RetJSON
+func (app App) shouldWriteProblem(retJson fastcurd.RetJSON) bool {
+	if errs.HTTPStatus(retJson.Code) < http.StatusBadRequest {
+		return false
+	}
+	switch app.GetErrorFormat() {
+	case ErrorFormatProblem:
+		return true
+	case ErrorFormatNegotiate:
+		return strings.Contains(app.C.GetHeader(HeaderAccept), ContentTypeProblemJSON)
+	default:
+		return false
+	}
+}
+func (app App) writeProblem(status int, retJson fastcurd.RetJSON) {
+	problem := app.NewProblem(status, retJson)
+	app.C.Writer.Header().Set(HeaderContentType, ContentTypeProblemJSON)
+	app.C.Writer.WriteHeader(problem.Status)
+	if err := json.NewEncoder(app.C.Writer).Encode(problem); err != nil {
+		_ = app.C.Error(err)
+	}
+	app.C.Abort()
+}
@@ -0,0 +1,80 @@
+package ginApp
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/fastcurd"
+)
+
+const (
+	KeyResp      = "resp"
+	KeyRespHooks = "bdk.respHooks"
+)
+
+var (
+	respHooks   []RespHook
+	respHooksMu sync.RWMutex
+)
+
+type (
+	// RespHook 在响应写入前执行 可修改响应体或通过 app.C.Header 设置响应头
+	RespHook func(app App, status int, resp *fastcurd.RetJSON)
+)
+
+// RegisterRespHook 注册全局响应钩子 按注册顺序执行
+func RegisterRespHook(hooks ...RespHook) {
+	respHooksMu.Lock()
+	defer respHooksMu.Unlock()
+	respHooks = append(respHooks, hooks...)
+}
+
+// AddRespHook 为当前请求追加响应钩子 在全局钩子之后执行
+func AddRespHook(c *gin.Context, hooks ...RespHook) {
+	list, _ := c.Get(KeyRespHooks)
+	ctxHooks, _ := list.([]RespHook)
+	c.Set(KeyRespHooks, append(ctxHooks[:len(ctxHooks):len(ctxHooks)], hooks...))
+}
+
+// RespHookReqID 在响应体的 extra 中返回请求id
+func RespHookReqID(app App, _ int, resp *fastcurd.RetJSON) {
+	reqID := app.GetReqID()
+	if reqID == "" {
+		return
+	}
+	getRespExtra(resp).ReqID = reqID
+}
+
+// RespHookProcTime 在响应体的 extra 中返回处理耗时
+func RespHookProcTime(app App, _ int, resp *fastcurd.RetJSON) {
+	getRespExtra(resp).ProcTime = app.GetProcTime()
+}
+
+func GetCtxRespVal(c *gin.Context) *fastcurd.RetJSON {
+	if resp, ok := c.Get(KeyResp); ok {
+		return resp.(*fastcurd.RetJSON)
+	}
+	return nil
+}
+func SetCtxRespVal(c *gin.Context, json *fastcurd.RetJSON) {
+	c.Set(KeyResp, json)
+}
+
+func (app App) getRespHooks() []RespHook {
+	respHooksMu.RLock()
+	hooks := respHooks[:len(respHooks):len(respHooks)]
+	respHooksMu.RUnlock()
+	if list, ok := app.C.Get(KeyRespHooks); ok {
+		if ctxHooks, ok := list.([]RespHook); ok {
+			hooks = append(hooks, ctxHooks...)
+		}
+	}
+	return hooks
+}
+func getRespExtra(resp *fastcurd.RetJSON) *fastcurd.RespJsonExtra {
+	if resp.Extra == nil {
+		resp.Extra = &fastcurd.RespJsonExtra{}
+	}
+	return resp.Extra
+}
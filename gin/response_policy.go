@@ -0,0 +1,67 @@
+package ginApp
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/errs"
+	"github.com/real-web-world/bdk/fastcurd"
+)
+
+const (
+	KeyResponsePolicy = "bdk.responsePolicy"
+)
+
+var (
+	// LegacyResponsePolicy 保持各响应方法原有的http状态码 业务错误通过 RetJSON.Code 区分
+	// errs.Error 通过 WithStatus 指定的状态码同样被忽略
+	LegacyResponsePolicy ResponsePolicy = legacyResponsePolicy{}
+	// HonestResponsePolicy 业务错误按 errs 注册表映射为真实的http状态码(400,422,429,500...)
+	// 成功响应保持原状态码 响应体不变
+	HonestResponsePolicy ResponsePolicy = ResponsePolicyFunc(func(code fastcurd.Code, status int) int {
+		if code == fastcurd.CodeOk {
+			return status
+		}
+		return errs.HTTPStatus(code)
+	})
+	defaultResponsePolicy = LegacyResponsePolicy
+)
+
+type (
+	// ResponsePolicy 根据业务码与响应方法给出的状态码决定最终的http状态码
+	ResponsePolicy interface {
+		HTTPStatus(code fastcurd.Code, status int) int
+	}
+	ResponsePolicyFunc   func(code fastcurd.Code, status int) int
+	legacyResponsePolicy struct{}
+)
+
+func (legacyResponsePolicy) HTTPStatus(_ fastcurd.Code, status int) int {
+	return status
+}
+
+func (f ResponsePolicyFunc) HTTPStatus(code fastcurd.Code, status int) int {
+	return f(code, status)
+}
+
+func isLegacyResponsePolicy(p ResponsePolicy) bool {
+	_, ok := p.(legacyResponsePolicy)
+	return ok
+}
+
+// SetDefaultResponsePolicy 设置全局默认策略 未通过 SetResponsePolicy 指定时使用
+func SetDefaultResponsePolicy(p ResponsePolicy) {
+	defaultResponsePolicy = p
+}
+
+// SetResponsePolicy 为当前请求指定策略 通常由中间件按engine或路由组设置
+func SetResponsePolicy(c *gin.Context, p ResponsePolicy) {
+	c.Set(KeyResponsePolicy, p)
+}
+func (app App) GetResponsePolicy() ResponsePolicy {
+	if p, ok := app.C.Get(KeyResponsePolicy); ok {
+		if policy, ok := p.(ResponsePolicy); ok {
+			return policy
+		}
+	}
+	return defaultResponsePolicy
+}
@@ -0,0 +1,77 @@
+package ginApp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/errs"
+	"github.com/real-web-world/bdk/fastcurd"
+)
+
+func TestErrorResponsePolicy(t *testing.T) {
+	conflict := errs.New(fastcurd.CodeBadReq, "").WithStatus(http.StatusConflict)
+	cases := []struct {
+		name   string
+		policy ResponsePolicy
+		err    error
+		want   int
+	}{
+		{name: "legacy ignores explicit status", policy: LegacyResponsePolicy, err: conflict, want: http.StatusOK},
+		{name: "legacy bad req", policy: LegacyResponsePolicy, err: errs.New(fastcurd.CodeBadReq, ""), want: http.StatusOK},
+		{name: "legacy no login", policy: LegacyResponsePolicy, err: errs.New(fastcurd.CodeNoLogin, ""), want: http.StatusUnauthorized},
+		{name: "legacy rate limit", policy: LegacyResponsePolicy, err: errs.New(fastcurd.CodeRateLimitError, ""), want: http.StatusTooManyRequests},
+		{name: "honest explicit status", policy: HonestResponsePolicy, err: conflict, want: http.StatusConflict},
+		{name: "honest registered code", policy: HonestResponsePolicy, err: errs.New(fastcurd.CodeBadReq, ""), want: http.StatusBadRequest},
+		{name: "honest no change", policy: HonestResponsePolicy, err: errs.New(fastcurd.CodeNoChange, ""), want: http.StatusOK},
+		{name: "custom policy explicit status", policy: ResponsePolicyFunc(func(_ fastcurd.Code, status int) int {
+			return status
+		}), err: conflict, want: http.StatusConflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			SetResponsePolicy(c, tc.policy)
+			GetApp(c).Error(tc.err)
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+			if resp := GetCtxRespVal(c); resp.Code != errs.As(tc.err).Code {
+				t.Fatalf("code = %d, want %d", resp.Code, errs.As(tc.err).Code)
+			}
+		})
+	}
+}
+
+func TestNoChangeResponsePolicy(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy ResponsePolicy
+	}{
+		{name: "legacy", policy: LegacyResponsePolicy},
+		{name: "honest", policy: HonestResponsePolicy},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			SetResponsePolicy(c, tc.policy)
+			SetErrorFormat(c, ErrorFormatProblem)
+			GetApp(c).NoChange()
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get(HeaderContentType); got != ContentTypeJSON {
+				t.Fatalf("content type = %s, want %s", got, ContentTypeJSON)
+			}
+			if resp := GetCtxRespVal(c); resp.Code != fastcurd.CodeNoChange {
+				t.Fatalf("code = %d, want %d", resp.Code, fastcurd.CodeNoChange)
+			}
+		})
+	}
+}
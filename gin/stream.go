@@ -0,0 +1,221 @@
+package ginApp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/json"
+)
+
+const (
+	ContentTypeEventStream = "text/event-stream"
+	ContentTypeNDJSON      = "application/x-ndjson"
+	HeaderLastEventID      = "Last-Event-ID"
+	HeaderCacheControl     = "Cache-Control"
+	// DefaultSSEKeepAlive 默认心跳间隔 避免代理因空闲断开连接
+	DefaultSSEKeepAlive = 15 * time.Second
+)
+
+var (
+	ErrStreamClosed = errors.New("stream closed")
+)
+
+type (
+	// SSEEvent Data为字符串时原样发送 其他类型编码为json
+	SSEEvent struct {
+		ID    string
+		Event string
+		Data  any
+		Retry time.Duration
+	}
+	// SSEStream 仅在 App.SSE 的回调中有效 回调返回后 Send 返回 ErrStreamClosed
+	SSEStream struct {
+		mu          sync.Mutex
+		c           *gin.Context
+		ctx         context.Context
+		cancel      context.CancelFunc
+		closed      bool
+		wg          sync.WaitGroup
+		lastEventID string
+	}
+	// NDJSONStream 仅在 App.NDJSON 的回调中有效 回调返回后 Write 返回 ErrStreamClosed
+	NDJSONStream struct {
+		mu     sync.Mutex
+		c      *gin.Context
+		ctx    context.Context
+		closed bool
+		enc    interface{ Encode(v any) error }
+	}
+)
+
+// SSE 开始 Server-Sent Events 响应并执行fn keepAlive 为0时不发送心跳
+// 客户端断开后 Done 关闭 Send 返回错误 fn返回后停止心跳并等待其退出 之后不再写入gin上下文
+// 返回fn的错误 此时响应已开始 无法再响应错误
+//
+//	err := app.SSE(func(s *ginApp.SSEStream) error {
+//		for msg := range ch {
+//			if err := s.SendData(msg); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	})
+func (app App) SSE(fn func(s *SSEStream) error, keepAliveParam ...time.Duration) error {
+	keepAlive := DefaultSSEKeepAlive
+	if len(keepAliveParam) > 0 {
+		keepAlive = keepAliveParam[0]
+	}
+	ctx, cancel := context.WithCancel(app.C.Request.Context())
+	s := &SSEStream{
+		c:           app.C,
+		ctx:         ctx,
+		cancel:      cancel,
+		lastEventID: app.C.GetHeader(HeaderLastEventID),
+	}
+	header := app.C.Writer.Header()
+	header.Set(HeaderContentType, ContentTypeEventStream)
+	header.Set(HeaderCacheControl, "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	app.C.Writer.WriteHeader(http.StatusOK)
+	app.C.Writer.Flush()
+	app.C.Abort()
+	if keepAlive > 0 {
+		s.wg.Add(1)
+		go s.keepAlive(keepAlive)
+	}
+	defer s.close()
+	return fn(s)
+}
+
+// LastEventID 客户端重连时携带的最后一个事件id 用于断点续传
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done 客户端断开或回调返回后关闭
+func (s *SSEStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+func (s *SSEStream) Context() context.Context {
+	return s.ctx
+}
+
+// close 停止心跳 持有锁标记关闭 保证返回后没有进行中的写入
+func (s *SSEStream) close() {
+	s.cancel()
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+func (s *SSEStream) SendData(data any) error {
+	return s.Send(SSEEvent{Data: data})
+}
+func (s *SSEStream) Send(event SSEEvent) error {
+	var sb strings.Builder
+	if event.ID != "" {
+		sb.WriteString("id: " + trimLineBreak(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		sb.WriteString("event: " + trimLineBreak(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		sb.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	data, ok := event.Data.(string)
+	if !ok && event.Data != nil {
+		bts, err := json.Marshal(event.Data)
+		if err != nil {
+			return err
+		}
+		data = string(bts)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		sb.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	sb.WriteString("\n")
+	return s.write(sb.String())
+}
+
+func (s *SSEStream) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+	if _, err := io.WriteString(s.c.Writer, msg); err != nil {
+		s.cancel()
+		return err
+	}
+	s.c.Writer.Flush()
+	return nil
+}
+func (s *SSEStream) keepAlive(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.write(": ping\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// NDJSON 开始分块的 application/x-ndjson 响应并执行fn 每次 Write 输出一行json
+// fn返回后不再写入gin上下文 返回fn的错误
+func (app App) NDJSON(fn func(s *NDJSONStream) error) error {
+	header := app.C.Writer.Header()
+	header.Set(HeaderContentType, ContentTypeNDJSON)
+	header.Set(HeaderCacheControl, "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	app.C.Writer.WriteHeader(http.StatusOK)
+	app.C.Writer.Flush()
+	app.C.Abort()
+	s := &NDJSONStream{
+		c:   app.C,
+		ctx: app.C.Request.Context(),
+		enc: json.NewEncoder(app.C.Writer),
+	}
+	defer s.close()
+	return fn(s)
+}
+
+// Done 客户端断开后关闭
+func (s *NDJSONStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+func (s *NDJSONStream) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+}
+func (s *NDJSONStream) Write(v any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	s.c.Writer.Flush()
+	return nil
+}
+
+func trimLineBreak(str string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(str)
+}
@@ -0,0 +1,116 @@
+package ginApp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestSSE(t *testing.T) {
+	errFn := errors.New("fn failed")
+	cases := []struct {
+		name      string
+		events    []SSEEvent
+		keepAlive time.Duration
+		fnErr     error
+		want      []string
+	}{
+		{
+			name:   "string data",
+			events: []SSEEvent{{ID: "1", Event: "msg", Data: "a\nb"}},
+			want:   []string{"id: 1\nevent: msg\ndata: a\ndata: b\n\n"},
+		},
+		{
+			name:   "json data and retry",
+			events: []SSEEvent{{Data: map[string]int{"n": 1}, Retry: time.Second}},
+			want:   []string{"retry: 1000\ndata: {\"n\":1}\n\n"},
+		},
+		{
+			name:   "line breaks stripped from id",
+			events: []SSEEvent{{ID: "1\n2", Data: "x"}},
+			want:   []string{"id: 12\ndata: x\n\n"},
+		},
+		{
+			name:      "keep alive",
+			keepAlive: time.Millisecond,
+			want:      []string{": ping\n\n"},
+		},
+		{name: "fn error returned", fnErr: errFn},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stream *SSEStream
+			var sseErr error
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) {
+				sseErr = GetApp(c).SSE(func(s *SSEStream) error {
+					stream = s
+					for _, event := range tc.events {
+						if err := s.Send(event); err != nil {
+							return err
+						}
+					}
+					if tc.keepAlive > 0 {
+						time.Sleep(20 * tc.keepAlive)
+					}
+					return tc.fnErr
+				}, tc.keepAlive)
+			})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			// 回调返回后心跳已停止 读取响应体不应与其竞争
+			body := w.Body.String()
+			if !errors.Is(sseErr, tc.fnErr) {
+				t.Fatalf("err = %v, want %v", sseErr, tc.fnErr)
+			}
+			if ct := w.Header().Get(HeaderContentType); ct != ContentTypeEventStream {
+				t.Fatalf("content type = %q", ct)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(body, want) {
+					t.Fatalf("body %q missing %q", body, want)
+				}
+			}
+			if err := stream.SendData("late"); !errors.Is(err, ErrStreamClosed) {
+				t.Fatalf("send after return err = %v", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+			if w.Body.String() != body {
+				t.Fatal("stream written after callback returned")
+			}
+		})
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	var stream *NDJSONStream
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		_ = GetApp(c).NDJSON(func(s *NDJSONStream) error {
+			stream = s
+			for i := range 3 {
+				if err := s.Write(map[string]int{"i": i}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want := "{\"i\":0}\n{\"i\":1}\n{\"i\":2}\n"; w.Body.String() != want {
+		t.Fatalf("body = %q, want %q", w.Body.String(), want)
+	}
+	if err := stream.Write(1); !errors.Is(err, ErrStreamClosed) {
+		t.Fatalf("write after return err = %v", err)
+	}
+}
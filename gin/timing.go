@@ -0,0 +1,121 @@
+package ginApp
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/real-web-world/bdk/fastcurd"
+)
+
+const (
+	HeaderServerTiming = "Server-Timing"
+	KeyProcBeginTime   = "bdk.procBeginTime"
+	KeyProcEndTime     = "bdk.procEndTime"
+	KeyTimingSpans     = "bdk.timingSpans"
+	serverTimingTotal  = "total"
+)
+
+type (
+	TimingSpan struct {
+		Name string
+		Desc string
+		Dur  time.Duration
+	}
+	timingSpans struct {
+		mu   sync.Mutex
+		list []TimingSpan
+	}
+)
+
+func SetProcBeginTime(c *gin.Context) time.Time {
+	now := time.Now()
+	c.Set(KeyProcBeginTime, now)
+	c.Set(KeyTimingSpans, &timingSpans{})
+	return now
+}
+
+// SetProcEndTime 记录处理结束时间 已记录时返回已有的时间
+func SetProcEndTime(c *gin.Context) time.Time {
+	if endTime := c.GetTime(KeyProcEndTime); !endTime.IsZero() {
+		return endTime
+	}
+	now := time.Now()
+	c.Set(KeyProcEndTime, now)
+	return now
+}
+
+// AddSpan 记录一段命名耗时 如 db cache render 未经过 Timing 中间件时忽略
+func (app App) AddSpan(name string, dur time.Duration, desc ...string) {
+	spans := app.getTimingSpans()
+	if spans == nil {
+		return
+	}
+	span := TimingSpan{Name: name, Dur: dur}
+	if len(desc) > 0 {
+		span.Desc = desc[0]
+	}
+	spans.mu.Lock()
+	spans.list = append(spans.list, span)
+	spans.mu.Unlock()
+}
+
+// StartSpan 开始计时 调用返回的函数结束并记录
+func (app App) StartSpan(name string, desc ...string) func() {
+	begin := time.Now()
+	return func() {
+		app.AddSpan(name, time.Since(begin), desc...)
+	}
+}
+func (app App) GetSpans() []TimingSpan {
+	spans := app.getTimingSpans()
+	if spans == nil {
+		return nil
+	}
+	spans.mu.Lock()
+	defer spans.mu.Unlock()
+	return append([]TimingSpan(nil), spans.list...)
+}
+
+// ServerTiming 生成 Server-Timing 响应头 包含各段耗时以及总耗时
+func (app App) ServerTiming() string {
+	var sb strings.Builder
+	for _, span := range app.GetSpans() {
+		writeServerTimingMetric(&sb, span.Name, span.Desc, span.Dur)
+	}
+	if !app.beginTime.IsZero() {
+		writeServerTimingMetric(&sb, serverTimingTotal, "", app.GetProcTime())
+	}
+	return sb.String()
+}
+
+// RespHookTiming 在响应体的 extra 中返回处理耗时 并设置 Server-Timing 响应头
+func RespHookTiming(app App, status int, resp *fastcurd.RetJSON) {
+	RespHookProcTime(app, status, resp)
+	if serverTiming := app.ServerTiming(); serverTiming != "" {
+		app.C.Header(HeaderServerTiming, serverTiming)
+	}
+}
+
+func (app App) getTimingSpans() *timingSpans {
+	if spans, ok := app.C.Get(KeyTimingSpans); ok {
+		return spans.(*timingSpans)
+	}
+	return nil
+}
+func writeServerTimingMetric(sb *strings.Builder, name, desc string, dur time.Duration) {
+	if sb.Len() > 0 {
+		sb.WriteString(", ")
+	}
+	sb.WriteString(name)
+	if desc != "" {
+		sb.WriteString(`;desc="`)
+		sb.WriteString(strings.ReplaceAll(desc, `"`, `'`))
+		sb.WriteString(`"`)
+	}
+	sb.WriteString(";dur=")
+	sb.WriteString(strconv.FormatFloat(float64(dur.Microseconds())/1000, 'f', -1, 64))
+}
@@ -10,6 +10,7 @@ import (
 const (
 	HeadUserAgent   = "User-Agent"
 	HeadContentType = "Content-Type"
+	HeadReqID       = "X-Request-ID"
 )
 
 // req path
@@ -58,6 +59,10 @@ type (
 		More   bool   `json:"more"`
 		Count  int64  `json:"count"`
 	}
+	// ReqIDTransport 调用下游服务时透传请求上下文中的请求id
+	ReqIDTransport struct {
+		Base http.RoundTripper
+	}
 )
 
 func IsSkipLogReq(req *http.Request, statusCode int) bool {
@@ -77,3 +82,20 @@ func GetUA(r *http.Request) string {
 func GetContentType(r *http.Request) string {
 	return r.Header.Get(HeadContentType)
 }
+
+func NewReqIDTransport(base http.RoundTripper) *ReqIDTransport {
+	return &ReqIDTransport{Base: base}
+}
+func (t *ReqIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	reqID := GetReqID(req.Context())
+	if reqID == "" || req.Header.Get(HeadReqID) != "" {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(HeadReqID, reqID)
+	return base.RoundTrip(req)
+}
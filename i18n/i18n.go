@@ -0,0 +1,142 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	LocaleZh = "zh"
+	LocaleEn = "en"
+)
+
+var (
+	// DefaultLocale 找不到对应语言的翻译时使用
+	DefaultLocale  = LocaleZh
+	defaultCatalog = NewCatalog()
+)
+
+type (
+	// Catalog 消息目录 locale -> 消息id -> 消息模板(fmt格式)
+	Catalog struct {
+		mu   sync.RWMutex
+		msgs map[string]map[string]string
+	}
+	// Error 可翻译的错误 Error() 按 DefaultLocale 渲染
+	Error struct {
+		ID   string
+		Args []any
+	}
+)
+
+func NewCatalog() *Catalog {
+	return &Catalog{
+		msgs: make(map[string]map[string]string),
+	}
+}
+
+// Register 注册消息 已存在的id会被覆盖
+func (c *Catalog) Register(locale string, msgs map[string]string) {
+	locale = normalizeLocale(locale)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	localeMsgs, ok := c.msgs[locale]
+	if !ok {
+		localeMsgs = make(map[string]string, len(msgs))
+		c.msgs[locale] = localeMsgs
+	}
+	for id, msg := range msgs {
+		localeMsgs[id] = msg
+	}
+}
+
+// Locales 已注册的语言
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	list := make([]string, 0, len(c.msgs))
+	for locale := range c.msgs {
+		list = append(list, locale)
+	}
+	return list
+}
+
+// Lookup 依次查找 locale, locale的基础语言(zh-CN -> zh), DefaultLocale
+func (c *Catalog) Lookup(locale, id string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	locale = normalizeLocale(locale)
+	for _, item := range []string{locale, baseLocale(locale), DefaultLocale} {
+		if msg, ok := c.msgs[item][id]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// T 翻译消息 找不到时id作为消息模板
+func (c *Catalog) T(locale, id string, args ...any) string {
+	msg, ok := c.Lookup(locale, id)
+	if !ok {
+		msg = id
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+func Register(locale string, msgs map[string]string) {
+	defaultCatalog.Register(locale, msgs)
+}
+func Locales() []string {
+	return defaultCatalog.Locales()
+}
+func T(locale, id string, args ...any) string {
+	return defaultCatalog.T(locale, id, args...)
+}
+
+// CodeID 业务码对应的消息id
+func CodeID(code int) string {
+	return "bdk.code." + strconv.Itoa(code)
+}
+
+// CodeMsg 业务码对应的消息 未注册时返回空字符串
+func CodeMsg(locale string, code int) string {
+	if msg, ok := defaultCatalog.Lookup(locale, CodeID(code)); ok {
+		return msg
+	}
+	return ""
+}
+
+func NewError(id string, args ...any) error {
+	return &Error{ID: id, Args: args}
+}
+func (e *Error) Error() string {
+	return e.Translate(DefaultLocale)
+}
+func (e *Error) Translate(locale string) string {
+	return T(locale, e.ID, e.Args...)
+}
+
+// TranslateErr 可翻译的错误按locale翻译 其他错误返回 err.Error()
+func TranslateErr(locale string, err error) string {
+	var actErr *Error
+	if errors.As(err, &actErr) {
+		return actErr.Translate(locale)
+	}
+	return err.Error()
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+}
+func baseLocale(locale string) string {
+	if idx := strings.Index(locale, "-"); idx > 0 {
+		return locale[:idx]
+	}
+	return locale
+}
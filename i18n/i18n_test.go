@@ -0,0 +1,50 @@
+package i18n
+
+import "testing"
+
+func TestCatalogT(t *testing.T) {
+	c := NewCatalog()
+	c.Register(LocaleZh, map[string]string{"greet": "你好 %s", "only.zh": "仅中文"})
+	c.Register(LocaleEn, map[string]string{"greet": "hello %s"})
+	cases := []struct {
+		name   string
+		locale string
+		id     string
+		args   []any
+		want   string
+	}{
+		{name: "exact locale", locale: "en", id: "greet", args: []any{"bob"}, want: "hello bob"},
+		{name: "region falls back to base", locale: "en_US", id: "greet", args: []any{"bob"}, want: "hello bob"},
+		{name: "missing id uses default locale", locale: "en", id: "only.zh", want: "仅中文"},
+		{name: "unknown locale uses default", locale: "fr", id: "greet", args: []any{"bob"}, want: "你好 bob"},
+		{name: "unknown id returned as is", locale: "en", id: "raw message", want: "raw message"},
+		{name: "unknown id keeps percent without args", locale: "en", id: "100%", want: "100%"},
+		{name: "unknown id formatted with args", locale: "en", id: "order %d not found", args: []any{42}, want: "order 42 not found"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := c.T(tc.locale, tc.id, tc.args...); got != tc.want {
+				t.Fatalf("T() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNegotiateFrom(t *testing.T) {
+	supported := []string{LocaleZh, LocaleEn}
+	cases := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "en-US,en;q=0.9", want: LocaleEn},
+		{accept: "fr,en;q=0.5", want: LocaleEn},
+		{accept: "zh-CN", want: LocaleZh},
+		{accept: "fr", want: DefaultLocale},
+		{accept: "", want: DefaultLocale},
+	}
+	for _, tc := range cases {
+		if got := NegotiateFrom(tc.accept, supported); got != tc.want {
+			t.Errorf("NegotiateFrom(%q) = %q, want %q", tc.accept, got, tc.want)
+		}
+	}
+}
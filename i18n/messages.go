@@ -0,0 +1,63 @@
+package i18n
+
+// 内置消息id
+const (
+	MsgServerBad                   = "bdk.serverBad"
+	MsgBadReq                      = "bdk.badReq"
+	MsgNoChange                    = "bdk.noChange"
+	MsgNoAuth                      = "bdk.noAuth"
+	MsgNoLogin                     = "bdk.noLogin"
+	MsgReqFrequency                = "bdk.reqFrequency"
+	MsgParamRequired               = "bdk.paramRequired"
+	MsgFastcurdCondValMustBeString = "bdk.fastcurd.condValMustBeString"
+	MsgFastcurdCondValMustBeArray  = "bdk.fastcurd.condValMustBeArray"
+	MsgValidBoolStr                = "bdk.valid.validBoolStr"
+	MsgValidPhone                  = "bdk.valid.phone"
+	MsgValidPhoneOrEmpty           = "bdk.valid.phoneOrEmpty"
+	MsgIdempotencyKeyRequired      = "bdk.idempotency.keyRequired"
+	MsgIdempotencyKeyMismatch      = "bdk.idempotency.keyMismatch"
+	MsgIdempotencyInProgress       = "bdk.idempotency.inProgress"
+	MsgBodyTooLarge                = "bdk.bodyTooLarge"
+	MsgUnsupportedEncoding         = "bdk.unsupportedEncoding"
+)
+
+func init() {
+	Register(LocaleZh, map[string]string{
+		MsgServerBad:                   "服务器开小差了~",
+		MsgBadReq:                      "错误的请求",
+		MsgNoChange:                    "无更新",
+		MsgNoAuth:                      "未授权",
+		MsgNoLogin:                     "未登录",
+		MsgReqFrequency:                "请求速度太快了~",
+		MsgParamRequired:               "请求参数必填",
+		MsgFastcurdCondValMustBeString: "筛选条件为%s时,val必须为字符串",
+		MsgFastcurdCondValMustBeArray:  "筛选条件为%s时,val必须为数组",
+		MsgValidBoolStr:                "{0}必须为true或false",
+		MsgValidPhone:                  "{0}必须是一个有效的手机号",
+		MsgValidPhoneOrEmpty:           "{0}必须为空或是一个有效的手机号",
+		MsgIdempotencyKeyRequired:      "缺少 Idempotency-Key 请求头",
+		MsgIdempotencyKeyMismatch:      "Idempotency-Key 已用于不同的请求",
+		MsgIdempotencyInProgress:       "相同 Idempotency-Key 的请求正在处理中",
+		MsgBodyTooLarge:                "请求体过大",
+		MsgUnsupportedEncoding:         "不支持的请求体编码 %s",
+	})
+	Register(LocaleEn, map[string]string{
+		MsgServerBad:                   "The server is busy, please try again later~",
+		MsgBadReq:                      "Bad request",
+		MsgNoChange:                    "Nothing changed",
+		MsgNoAuth:                      "Unauthorized",
+		MsgNoLogin:                     "Not logged in",
+		MsgReqFrequency:                "Too many requests~",
+		MsgParamRequired:               "Request parameters are required",
+		MsgFastcurdCondValMustBeString: "val must be a string when filter condition is %s",
+		MsgFastcurdCondValMustBeArray:  "val must be an array when filter condition is %s",
+		MsgValidBoolStr:                "{0} must be true or false",
+		MsgValidPhone:                  "{0} must be a valid phone number",
+		MsgValidPhoneOrEmpty:           "{0} must be empty or a valid phone number",
+		MsgIdempotencyKeyRequired:      "Idempotency-Key header is required",
+		MsgIdempotencyKeyMismatch:      "Idempotency-Key has been used for a different request",
+		MsgIdempotencyInProgress:       "A request with the same Idempotency-Key is being processed",
+		MsgBodyTooLarge:                "Request body too large",
+		MsgUnsupportedEncoding:         "Unsupported content encoding %s",
+	})
+}
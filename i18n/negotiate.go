@@ -0,0 +1,36 @@
+package i18n
+
+import (
+	"slices"
+
+	"golang.org/x/text/language"
+)
+
+// Negotiate 从 Accept-Language 中选出已注册的语言 无匹配时返回 DefaultLocale
+func Negotiate(acceptLanguage string) string {
+	return NegotiateFrom(acceptLanguage, Locales())
+}
+func NegotiateFrom(acceptLanguage string, supported []string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return DefaultLocale
+	}
+	for _, tag := range tags {
+		locale := normalizeLocale(tag.String())
+		if slices.Contains(supported, locale) {
+			return locale
+		}
+		base, _ := tag.Base()
+		if slices.Contains(supported, base.String()) {
+			return base.String()
+		}
+	}
+	return DefaultLocale
+}
+
+// Supported locale(或其基础语言)是否已注册
+func Supported(locale string) bool {
+	locale = normalizeLocale(locale)
+	locales := Locales()
+	return slices.Contains(locales, locale) || slices.Contains(locales, baseLocale(locale))
+}
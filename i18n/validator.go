@@ -0,0 +1,81 @@
+package i18n
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTrans "github.com/go-playground/validator/v10/translations/en"
+	zhTrans "github.com/go-playground/validator/v10/translations/zh"
+)
+
+var (
+	uniTrans   = ut.New(zh.New(), zh.New(), en.New())
+	uniTransMu sync.RWMutex
+	// bdk 自定义校验规则 -> 消息id
+	customValidMsgMap = map[string]string{
+		"validBoolStr": MsgValidBoolStr,
+		"phone":        MsgValidPhone,
+		"phoneOrEmpty": MsgValidPhoneOrEmpty,
+	}
+	defaultTranslationFns = map[string]func(v *validator.Validate, trans ut.Translator) error{
+		LocaleZh: zhTrans.RegisterDefaultTranslations,
+		LocaleEn: enTrans.RegisterDefaultTranslations,
+	}
+)
+
+// RegisterValidator 为校验器注册中英文翻译 以及bdk自定义规则的翻译
+func RegisterValidator(v *validator.Validate) error {
+	uniTransMu.Lock()
+	defer uniTransMu.Unlock()
+	for locale, registerFn := range defaultTranslationFns {
+		trans, _ := uniTrans.GetTranslator(locale)
+		if err := registerFn(v, trans); err != nil {
+			return err
+		}
+		for tag, msgID := range customValidMsgMap {
+			msg, ok := defaultCatalog.Lookup(locale, msgID)
+			if !ok {
+				continue
+			}
+			err := v.RegisterTranslation(tag, trans, func(trans ut.Translator) error {
+				return trans.Add(tag, msg, true)
+			}, translateCustom)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidatorTranslator 获取locale对应的校验翻译器 找不到时使用 DefaultLocale
+func ValidatorTranslator(locale string) ut.Translator {
+	uniTransMu.RLock()
+	defer uniTransMu.RUnlock()
+	locale = normalizeLocale(locale)
+	trans, _ := uniTrans.FindTranslator(strings.ReplaceAll(locale, "-", "_"), baseLocale(locale),
+		DefaultLocale)
+	return trans
+}
+
+// TranslateValidationErrors 将校验错误逐条翻译
+func TranslateValidationErrors(locale string, errs validator.ValidationErrors) []string {
+	trans := ValidatorTranslator(locale)
+	list := make([]string, 0, len(errs))
+	for _, fe := range errs {
+		list = append(list, fe.Translate(trans))
+	}
+	return list
+}
+
+func translateCustom(trans ut.Translator, fe validator.FieldError) string {
+	msg, err := trans.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+	return msg
+}
@@ -0,0 +1,106 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTTL = 24 * time.Hour
+	// DefaultLockTTL 处理中记录的过期时间 持有者崩溃后自动释放
+	DefaultLockTTL    = time.Minute
+	defaultGCInterval = time.Minute
+)
+
+var (
+	ErrNotLocked = errors.New("idempotency key not locked")
+)
+
+type (
+	// Record 首次请求的响应 Completed 为false时表示首次请求仍在处理中
+	Record struct {
+		Fingerprint string
+		Completed   bool
+		Status      int
+		Header      http.Header
+		Body        []byte
+		CreatedAt   time.Time
+	}
+	// Store 幂等记录存储 分布式场景可基于redis等实现
+	Store interface {
+		// Lock key不存在时写入处理中的记录并返回 acquired=true ttl为处理中记录的过期时间
+		// key已存在时返回已有记录 由调用方比较指纹与状态
+		Lock(ctx context.Context, key, fingerprint string, ttl time.Duration) (rec *Record, acquired bool, err error)
+		// Save 保存首次请求的响应 key需已被 Lock
+		Save(ctx context.Context, key string, rec *Record, ttl time.Duration) error
+		// Release 删除处理中的记录 首次请求失败时调用 以便客户端重试
+		Release(ctx context.Context, key string) error
+	}
+	MemoryStore struct {
+		mu         sync.Mutex
+		records    map[string]*memoryRecord
+		lastGC     time.Time
+		gcInterval time.Duration
+	}
+	memoryRecord struct {
+		rec      Record
+		expireAt time.Time
+	}
+)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records:    make(map[string]*memoryRecord),
+		lastGC:     time.Now(),
+		gcInterval: defaultGCInterval,
+	}
+}
+func (s *MemoryStore) Lock(_ context.Context, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gc(now)
+	if item, ok := s.records[key]; ok && now.Before(item.expireAt) {
+		rec := item.rec
+		return &rec, false, nil
+	}
+	rec := Record{Fingerprint: fingerprint, CreatedAt: now}
+	s.records[key] = &memoryRecord{rec: rec, expireAt: now.Add(ttl)}
+	return &rec, true, nil
+}
+func (s *MemoryStore) Save(_ context.Context, key string, rec *Record, ttl time.Duration) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.records[key]
+	if !ok || item.rec.Fingerprint != rec.Fingerprint {
+		return ErrNotLocked
+	}
+	item.rec = *rec
+	item.rec.Completed = true
+	item.expireAt = now.Add(ttl)
+	return nil
+}
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item, ok := s.records[key]; ok && !item.rec.Completed {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func (s *MemoryStore) gc(now time.Time) {
+	if now.Sub(s.lastGC) < s.gcInterval {
+		return
+	}
+	s.lastGC = now
+	for key, item := range s.records {
+		if now.After(item.expireAt) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name string
+		run  func(t *testing.T, s *MemoryStore)
+	}{
+		{name: "first lock acquired", run: func(t *testing.T, s *MemoryStore) {
+			_, acquired, err := s.Lock(ctx, "k", "fp", time.Minute)
+			if err != nil || !acquired {
+				t.Fatalf("acquired = %v, err = %v", acquired, err)
+			}
+		}},
+		{name: "second lock sees in progress", run: func(t *testing.T, s *MemoryStore) {
+			_, _, _ = s.Lock(ctx, "k", "fp", time.Minute)
+			rec, acquired, err := s.Lock(ctx, "k", "other", time.Minute)
+			if err != nil || acquired || rec.Completed || rec.Fingerprint != "fp" {
+				t.Fatalf("rec = %+v, acquired = %v, err = %v", rec, acquired, err)
+			}
+		}},
+		{name: "lock expires", run: func(t *testing.T, s *MemoryStore) {
+			_, _, _ = s.Lock(ctx, "k", "fp", time.Millisecond)
+			time.Sleep(5 * time.Millisecond)
+			if _, acquired, _ := s.Lock(ctx, "k", "fp", time.Minute); !acquired {
+				t.Fatal("expired lock not reacquired")
+			}
+		}},
+		{name: "save then replay", run: func(t *testing.T, s *MemoryStore) {
+			_, _, _ = s.Lock(ctx, "k", "fp", time.Millisecond)
+			if err := s.Save(ctx, "k", &Record{Fingerprint: "fp", Status: 201, Body: []byte("ok")}, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(5 * time.Millisecond)
+			rec, acquired, _ := s.Lock(ctx, "k", "fp", time.Minute)
+			if acquired || !rec.Completed || rec.Status != 201 || string(rec.Body) != "ok" {
+				t.Fatalf("rec = %+v, acquired = %v", rec, acquired)
+			}
+		}},
+		{name: "save without lock", run: func(t *testing.T, s *MemoryStore) {
+			if err := s.Save(ctx, "k", &Record{Fingerprint: "fp"}, time.Minute); !errors.Is(err, ErrNotLocked) {
+				t.Fatalf("err = %v", err)
+			}
+		}},
+		{name: "release in progress", run: func(t *testing.T, s *MemoryStore) {
+			_, _, _ = s.Lock(ctx, "k", "fp", time.Minute)
+			_ = s.Release(ctx, "k")
+			if _, acquired, _ := s.Lock(ctx, "k", "fp", time.Minute); !acquired {
+				t.Fatal("released lock not reacquired")
+			}
+		}},
+		{name: "release keeps completed", run: func(t *testing.T, s *MemoryStore) {
+			_, _, _ = s.Lock(ctx, "k", "fp", time.Minute)
+			_ = s.Save(ctx, "k", &Record{Fingerprint: "fp"}, time.Minute)
+			_ = s.Release(ctx, "k")
+			if _, acquired, _ := s.Lock(ctx, "k", "fp", time.Minute); acquired {
+				t.Fatal("completed record released")
+			}
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.run(t, NewMemoryStore())
+		})
+	}
+}
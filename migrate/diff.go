@@ -0,0 +1,132 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	queryPrefixArr = []string{"SELECT", "SHOW", "PRAGMA", "WITH", "DESC", "EXPLAIN"}
+)
+
+type (
+	// Skeleton 根据模型与线上表结构的差异生成的迁移骨架
+	Skeleton struct {
+		Version int64
+		Name    string
+		Up      []string
+		Down    []string
+	}
+)
+
+// Diff 比较 AutoMigrate 对 models 将要执行的 ddl 与线上表结构的差异 生成迁移骨架
+// 新建表会生成对应的 drop table, 其余变更的回滚语句需要手动补充
+// 注意 gorm 在 DryRun 模式下会将 ddl 同时打印到标准输出
+func Diff(db *gorm.DB, name string, models ...any) (*Skeleton, error) {
+	skeleton := &Skeleton{
+		Version: NewVersion(),
+		Name:    name,
+	}
+	for _, model := range models {
+		hasTable := db.Migrator().HasTable(model)
+		up, err := dryRunDDL(db, func(tx *gorm.DB) error {
+			return tx.AutoMigrate(model)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(up) == 0 {
+			continue
+		}
+		skeleton.Up = append(skeleton.Up, up...)
+		if !hasTable {
+			down, err := dryRunDDL(db, func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(model)
+			})
+			if err != nil {
+				return nil, err
+			}
+			skeleton.Down = append(down, skeleton.Down...)
+			continue
+		}
+		revert := make([]string, 0, len(up))
+		for i := len(up) - 1; i >= 0; i-- {
+			revert = append(revert, "-- TODO revert: "+up[i])
+		}
+		skeleton.Down = append(revert, skeleton.Down...)
+	}
+	return skeleton, nil
+}
+
+// NewVersion 以当前时间生成版本号 如 20060102150405
+func NewVersion() int64 {
+	version, _ := strconv.ParseInt(time.Now().Format("20060102150405"), 10, 64)
+	return version
+}
+
+func (s *Skeleton) IsEmpty() bool {
+	return len(s.Up) == 0
+}
+func (s *Skeleton) UpSQL() string {
+	return joinStatements(s.Up)
+}
+func (s *Skeleton) DownSQL() string {
+	return joinStatements(s.Down)
+}
+
+// FileName 返回 RegisterFS 可识别的文件名
+func (s *Skeleton) FileName(up bool) string {
+	suffix := downSuffix
+	if up {
+		suffix = upSuffix
+	}
+	return fmt.Sprintf("%d_%s%s", s.Version, s.Name, suffix)
+}
+
+// WriteFiles 将骨架写入 dir 下的 up/down sql 文件
+func (s *Skeleton) WriteFiles(dir string) error {
+	if err := os.WriteFile(filepath.Join(dir, s.FileName(true)), []byte(s.UpSQL()), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, s.FileName(false)), []byte(s.DownSQL()), 0o644)
+}
+
+func dryRunDDL(db *gorm.DB, fn func(tx *gorm.DB) error) ([]string, error) {
+	recorder := newSQLRecorder(db.Logger)
+	if err := fn(db.Session(&gorm.Session{DryRun: true, Logger: recorder})); err != nil {
+		return nil, err
+	}
+	list := make([]string, 0)
+	for _, stmt := range recorder.Statements() {
+		if !isQueryStatement(stmt) {
+			list = append(list, stmt)
+		}
+	}
+	return list, nil
+}
+func isQueryStatement(stmt string) bool {
+	upperStmt := strings.ToUpper(strings.TrimSpace(stmt))
+	for _, prefix := range queryPrefixArr {
+		if strings.HasPrefix(upperStmt, prefix) {
+			return true
+		}
+	}
+	return false
+}
+func joinStatements(list []string) string {
+	var sb strings.Builder
+	for _, stmt := range list {
+		sb.WriteString(stmt)
+		if !strings.HasPrefix(stmt, "--") {
+			sb.WriteString(";")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/real-web-world/bdk"
+)
+
+const (
+	lockID           = 1
+	lockPollInterval = 500 * time.Millisecond
+)
+
+type (
+	// lockRecord 迁移锁 依赖主键唯一性保证同一时间只有一个实例执行迁移
+	lockRecord struct {
+		ID       int       `gorm:"primaryKey;autoIncrement:false"`
+		Owner    string    `gorm:"size:128;not null"`
+		LockedAt time.Time `gorm:"not null"`
+	}
+)
+
+// withLock 持有锁执行fn 期间每 lockTTL/3 续期一次 锁被其他实例抢占时取消fn的ctx并返回 ErrLockLost
+// 试运行不建表也不加锁
+func (m *Migrator) withLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	db := m.db.WithContext(ctx)
+	if m.isDryRun() {
+		return fn(db)
+	}
+	if err := m.ensureTables(db); err != nil {
+		return err
+	}
+	owner, err := m.acquireLock(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer m.releaseLock(db, owner)
+	lockCtx, cancel := context.WithCancelCause(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.keepLock(lockCtx, cancel, owner)
+	}()
+	err = fn(m.db.WithContext(lockCtx))
+	cancel(nil)
+	wg.Wait()
+	if cause := context.Cause(lockCtx); errors.Is(cause, ErrLockLost) {
+		return cause
+	}
+	return err
+}
+
+// keepLock 定期刷新 locked_at 避免长时间的迁移被视为崩溃而被抢占
+func (m *Migrator) keepLock(ctx context.Context, cancel context.CancelCauseFunc, owner string) {
+	ticker := time.NewTicker(max(m.lockTTL/3, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			res := m.db.WithContext(ctx).Table(m.lockTableName).Where("id = ? and owner = ?", lockID, owner).
+				Update("locked_at", time.Now())
+			// 刷新出错时等待下次重试 锁已不属于当前实例时中止迁移
+			if res.Error == nil && res.RowsAffected == 0 {
+				cancel(ErrLockLost)
+				return
+			}
+		}
+	}
+}
+func (m *Migrator) acquireLock(ctx context.Context, db *gorm.DB) (string, error) {
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), bdk.RandomAlphaNum(8))
+	timeout := time.NewTimer(m.lockTimeout)
+	defer timeout.Stop()
+	for {
+		err := db.Table(m.lockTableName).Create(&lockRecord{
+			ID:       lockID,
+			Owner:    owner,
+			LockedAt: time.Now(),
+		}).Error
+		if err == nil {
+			return owner, nil
+		}
+		// 持有者可能已崩溃 清理过期的锁后重试
+		db.Table(m.lockTableName).Where("id = ? and locked_at < ?", lockID, time.Now().Add(-m.lockTTL)).
+			Delete(&lockRecord{})
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-timeout.C:
+			return "", ErrLockTimeout
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+func (m *Migrator) releaseLock(db *gorm.DB, owner string) {
+	db.WithContext(context.Background()).Table(m.lockTableName).Where("id = ? and owner = ?", lockID, owner).
+		Delete(&lockRecord{})
+}
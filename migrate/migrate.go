@@ -0,0 +1,331 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultTableName     = "bdk_migrations"
+	DefaultLockTableName = "bdk_migration_lock"
+	defaultLockTimeout   = time.Minute
+	defaultLockTTL       = 10 * time.Minute
+)
+
+var (
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	ErrUnknownVersion   = errors.New("applied migration not registered")
+	ErrNoDown           = errors.New("migration has no down")
+	ErrLockTimeout      = errors.New("wait migration lock timeout")
+	ErrLockLost         = errors.New("migration lock lost")
+)
+
+type (
+	// Migration 一个版本的迁移 Up/Down 与 UpSQL/DownSQL 二选一
+	Migration struct {
+		Version  int64
+		Name     string
+		Up       func(tx *gorm.DB) error
+		Down     func(tx *gorm.DB) error
+		UpSQL    string
+		DownSQL  string
+		Checksum string // 为空时根据sql或版本信息自动计算
+	}
+	// Record 迁移记录表
+	Record struct {
+		Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+		Name      string    `gorm:"size:255;not null"`
+		Checksum  string    `gorm:"size:64;not null"`
+		AppliedAt time.Time `gorm:"not null"`
+	}
+	Status struct {
+		Version   int64
+		Name      string
+		Applied   bool
+		AppliedAt *time.Time
+	}
+	Option   func(m *Migrator)
+	Migrator struct {
+		db            *gorm.DB
+		migrations    []Migration
+		tableName     string
+		lockTableName string
+		lockTimeout   time.Duration
+		lockTTL       time.Duration
+		dryRunOut     io.Writer
+	}
+)
+
+func WithTableName(name string) Option {
+	return func(m *Migrator) {
+		m.tableName = name
+	}
+}
+func WithLockTableName(name string) Option {
+	return func(m *Migrator) {
+		m.lockTableName = name
+	}
+}
+
+// WithLockTimeout 等待其他实例释放锁的最长时间
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+// WithLockTTL 超过该时间未续期的锁视为持有者已崩溃 可被抢占 持有期间每 ttl/3 续期一次
+func WithLockTTL(ttl time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTTL = ttl
+	}
+}
+
+// WithDryRun 只输出将要执行的sql 不执行也不记录 不创建迁移记录表与锁表
+func WithDryRun(w io.Writer) Option {
+	return func(m *Migrator) {
+		m.dryRunOut = w
+	}
+}
+
+func New(db *gorm.DB, opts ...Option) *Migrator {
+	m := &Migrator{
+		db:            db,
+		tableName:     DefaultTableName,
+		lockTableName: DefaultLockTableName,
+		lockTimeout:   defaultLockTimeout,
+		lockTTL:       defaultLockTTL,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *Migration) GetChecksum() string {
+	if m.Checksum != "" {
+		return m.Checksum
+	}
+	h := sha256.New()
+	if m.UpSQL != "" || m.DownSQL != "" {
+		_, _ = io.WriteString(h, m.UpSQL)
+		_, _ = io.WriteString(h, "\x00")
+		_, _ = io.WriteString(h, m.DownSQL)
+	} else {
+		_, _ = io.WriteString(h, strconv.FormatInt(m.Version, 10)+"_"+m.Name)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+func (m *Migration) hasDown() bool {
+	return m.Down != nil || m.DownSQL != ""
+}
+func (m *Migration) run(tx *gorm.DB, up bool) error {
+	fn, sql := m.Up, m.UpSQL
+	if !up {
+		fn, sql = m.Down, m.DownSQL
+	}
+	if fn != nil {
+		return fn(tx)
+	}
+	for _, stmt := range SplitStatements(sql) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Register 注册迁移 版本号不可重复
+func (m *Migrator) Register(migrations ...Migration) error {
+	for _, item := range migrations {
+		if slices.ContainsFunc(m.migrations, func(exist Migration) bool {
+			return exist.Version == item.Version
+		}) {
+			return fmt.Errorf("%w: %d", ErrDuplicateVersion, item.Version)
+		}
+		m.migrations = append(m.migrations, item)
+	}
+	slices.SortFunc(m.migrations, func(a, b Migration) int {
+		return compareInt64(a.Version, b.Version)
+	})
+	return nil
+}
+func (m *Migrator) Migrations() []Migration {
+	return slices.Clone(m.migrations)
+}
+
+// Up 执行所有未执行的迁移
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.UpTo(ctx, 0)
+}
+
+// UpTo 执行版本号不大于version的未执行迁移 version为0时执行全部
+func (m *Migrator) UpTo(ctx context.Context, version int64) error {
+	return m.withLock(ctx, func(db *gorm.DB) error {
+		applied, err := m.appliedRecords(db)
+		if err != nil {
+			return err
+		}
+		if err = m.verify(applied); err != nil {
+			return err
+		}
+		for _, item := range m.migrations {
+			if version > 0 && item.Version > version {
+				break
+			}
+			if _, ok := applied[item.Version]; ok {
+				continue
+			}
+			if err = m.apply(db, item, true); err != nil {
+				return fmt.Errorf("migrate up %d_%s: %w", item.Version, item.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Down 回滚最近执行的steps个迁移
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(db *gorm.DB) error {
+		applied, err := m.appliedRecords(db)
+		if err != nil {
+			return err
+		}
+		if err = m.verify(applied); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			item := m.migrations[i]
+			if _, ok := applied[item.Version]; !ok {
+				continue
+			}
+			if !item.hasDown() {
+				return fmt.Errorf("%w: %d_%s", ErrNoDown, item.Version, item.Name)
+			}
+			if err = m.apply(db, item, false); err != nil {
+				return fmt.Errorf("migrate down %d_%s: %w", item.Version, item.Name, err)
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// Status 所有已注册迁移的执行状态
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	db := m.db.WithContext(ctx)
+	if !m.isDryRun() {
+		if err := m.ensureTables(db); err != nil {
+			return nil, err
+		}
+	}
+	applied, err := m.appliedRecords(db)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Status, 0, len(m.migrations))
+	for _, item := range m.migrations {
+		status := Status{Version: item.Version, Name: item.Name}
+		if record, ok := applied[item.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &record.AppliedAt
+		}
+		list = append(list, status)
+	}
+	return list, nil
+}
+
+func (m *Migrator) isDryRun() bool {
+	return m.dryRunOut != nil
+}
+func (m *Migrator) apply(db *gorm.DB, item Migration, up bool) error {
+	if m.isDryRun() {
+		direction := "up"
+		if !up {
+			direction = "down"
+		}
+		recorder := newSQLRecorder(db.Logger)
+		tx := db.Session(&gorm.Session{DryRun: true, Logger: recorder})
+		if err := item.run(tx, up); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(m.dryRunOut, "-- %d_%s %s\n", item.Version, item.Name, direction)
+		for _, stmt := range recorder.Statements() {
+			if err != nil {
+				break
+			}
+			_, err = fmt.Fprintf(m.dryRunOut, "%s;\n", stmt)
+		}
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := item.run(tx, up); err != nil {
+			return err
+		}
+		if !up {
+			return tx.Table(m.tableName).Where("version = ?", item.Version).Delete(&Record{}).Error
+		}
+		return tx.Table(m.tableName).Create(&Record{
+			Version:   item.Version,
+			Name:      item.Name,
+			Checksum:  item.GetChecksum(),
+			AppliedAt: time.Now(),
+		}).Error
+	})
+}
+func (m *Migrator) verify(applied map[int64]Record) error {
+	for version, record := range applied {
+		idx := slices.IndexFunc(m.migrations, func(item Migration) bool {
+			return item.Version == version
+		})
+		if idx < 0 {
+			return fmt.Errorf("%w: %d_%s", ErrUnknownVersion, version, record.Name)
+		}
+		if m.migrations[idx].GetChecksum() != record.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, version, record.Name)
+		}
+	}
+	return nil
+}
+func (m *Migrator) ensureTables(db *gorm.DB) error {
+	if err := db.Table(m.tableName).AutoMigrate(&Record{}); err != nil {
+		return err
+	}
+	return db.Table(m.lockTableName).AutoMigrate(&lockRecord{})
+}
+func (m *Migrator) appliedRecords(db *gorm.DB) (map[int64]Record, error) {
+	var list []Record
+	// 试运行时记录表可能尚未创建 视为没有已执行的迁移
+	if m.isDryRun() && !db.Migrator().HasTable(m.tableName) {
+		return map[int64]Record{}, nil
+	}
+	if err := db.Table(m.tableName).Order("version").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]Record, len(list))
+	for _, record := range list {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
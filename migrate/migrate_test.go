@@ -0,0 +1,204 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openSQLite 使用文件库 迁移事务执行期间续期锁需要另一个连接
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return db
+}
+
+func TestSplitStatements(t *testing.T) {
+	cases := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{name: "empty", sql: " ;\n; ", want: nil},
+		{name: "simple", sql: "create table a (id int);\ninsert into a values (1)", want: []string{"create table a (id int)", "insert into a values (1)"}},
+		{name: "quoted semicolon", sql: `insert into a values ('x;y', "p;q", ` + "`c;d`" + `);select 1;`, want: []string{`insert into a values ('x;y', "p;q", ` + "`c;d`" + `)`, "select 1"}},
+		{name: "escaped quote", sql: "insert into a values ('it''s;ok');select 2", want: []string{"insert into a values ('it''s;ok')", "select 2"}},
+		{name: "line comment", sql: "-- drop; table\nselect 1; -- tail;", want: []string{"select 1"}},
+		{name: "block comment", sql: "/* a; b */select 1;/**/select 2", want: []string{"select 1", "select 2"}},
+		{name: "dollar quoted", sql: "create function f() returns int as $$ begin return 1; end $$ language plpgsql;select 1", want: []string{"create function f() returns int as $$ begin return 1; end $$ language plpgsql", "select 1"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SplitStatements(tc.sql); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("SplitStatements() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	db := openSQLite(t)
+	out := &bytes.Buffer{}
+	m := New(db, WithDryRun(out))
+	if err := m.Register(Migration{Version: 1, Name: "init", UpSQL: "create table a (id int);"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "-- 1_init up\ncreate table a (id int);") {
+		t.Fatalf("dry run output = %q", out.String())
+	}
+	if status, err := m.Status(context.Background()); err != nil || status[0].Applied {
+		t.Fatalf("Status() = %v, %v", status, err)
+	}
+	for _, table := range []string{DefaultTableName, DefaultLockTableName, "a"} {
+		if db.Migrator().HasTable(table) {
+			t.Fatalf("dry run created table %s", table)
+		}
+	}
+}
+
+func TestLockRefresh(t *testing.T) {
+	cases := []struct {
+		name    string
+		steal   bool // 执行期间其他实例抢占锁
+		wantErr error
+	}{
+		{name: "refreshed"},
+		{name: "lost", steal: true, wantErr: ErrLockLost},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := openSQLite(t)
+			ttl := 30 * time.Millisecond
+			m := New(db, WithLockTTL(ttl))
+			var lockedAt []time.Time
+			err := m.Register(Migration{Version: 1, Name: "slow", Up: func(_ *gorm.DB) error {
+				if tc.steal {
+					if err := db.Table(DefaultLockTableName).Where("id = ?", lockID).
+						Update("owner", "other").Error; err != nil {
+						return err
+					}
+				}
+				for range 3 {
+					time.Sleep(ttl)
+					var lock lockRecord
+					if err := db.Table(DefaultLockTableName).Take(&lock).Error; err != nil {
+						return err
+					}
+					lockedAt = append(lockedAt, lock.LockedAt)
+				}
+				return nil
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = m.Up(context.Background())
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Up() err = %v, want %v", err, tc.wantErr)
+			}
+			if !tc.steal && (len(lockedAt) != 3 || !lockedAt[2].After(lockedAt[0])) {
+				t.Fatalf("lock not refreshed: %v", lockedAt)
+			}
+		})
+	}
+}
+
+type (
+	diffUser struct {
+		ID   uint
+		Name string
+	}
+	diffUserV2 struct {
+		ID   uint
+		Name string
+		Age  int
+	}
+	diffOrder struct {
+		ID     uint
+		UserID uint
+	}
+)
+
+func (diffUser) TableName() string {
+	return "diff_user"
+}
+func (diffUserV2) TableName() string {
+	return "diff_user"
+}
+func (diffOrder) TableName() string {
+	return "diff_order"
+}
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		name     string
+		models   []any
+		wantUp   []string
+		wantDown []string
+	}{
+		{name: "no change", models: []any{&diffUser{}}},
+		{
+			name:     "add column",
+			models:   []any{&diffUserV2{}},
+			wantUp:   []string{"ALTER TABLE `diff_user` ADD `age` integer"},
+			wantDown: []string{"-- TODO revert: ALTER TABLE `diff_user` ADD `age` integer"},
+		},
+		{
+			name:     "add table",
+			models:   []any{&diffOrder{}},
+			wantUp:   []string{"CREATE TABLE `diff_order` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer)"},
+			wantDown: []string{"DROP TABLE IF EXISTS `diff_order`"},
+		},
+		{
+			name:   "down in reverse order",
+			models: []any{&diffUserV2{}, &diffOrder{}},
+			wantUp: []string{
+				"ALTER TABLE `diff_user` ADD `age` integer",
+				"CREATE TABLE `diff_order` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer)",
+			},
+			wantDown: []string{"DROP TABLE IF EXISTS `diff_order`", "-- TODO revert: ALTER TABLE `diff_user` ADD `age` integer"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := openSQLite(t)
+			if err := db.AutoMigrate(&diffUser{}); err != nil {
+				t.Fatal(err)
+			}
+			skeleton, err := Diff(db, "diff", tc.models...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skeleton.IsEmpty() != (len(tc.wantUp) == 0) || !reflect.DeepEqual(skeleton.Up, tc.wantUp) {
+				t.Fatalf("Up = %q, want %q", skeleton.Up, tc.wantUp)
+			}
+			if !reflect.DeepEqual(skeleton.Down, tc.wantDown) {
+				t.Fatalf("Down = %q, want %q", skeleton.Down, tc.wantDown)
+			}
+			if db.Migrator().HasTable(&diffOrder{}) || db.Migrator().HasColumn(&diffUserV2{}, "Age") {
+				t.Fatal("Diff changed the schema")
+			}
+		})
+	}
+}
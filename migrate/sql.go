@@ -0,0 +1,171 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/logger"
+)
+
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
+var (
+	// 文件名格式 {version}_{name}.up.sql / {version}_{name}.down.sql
+	sqlFileReg = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+type (
+	// sqlRecorder 记录 gorm DryRun 模式下生成的sql
+	sqlRecorder struct {
+		logger.Interface
+		mu         sync.Mutex
+		statements []string
+	}
+)
+
+// RegisterFS 从目录中加载sql迁移文件 可配合 embed.FS 使用
+func (m *Migrator) RegisterFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	migrationMap := make(map[int64]*Migration)
+	versions := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		matches := sqlFileReg.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("parse migration version %s: %w", entry.Name(), err)
+		}
+		bts, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		item, ok := migrationMap[version]
+		if !ok {
+			item = &Migration{Version: version, Name: matches[2]}
+			migrationMap[version] = item
+			versions = append(versions, version)
+		} else if item.Name != matches[2] {
+			return fmt.Errorf("%w: %s", ErrDuplicateVersion, entry.Name())
+		}
+		if matches[3] == "up" {
+			item.UpSQL = string(bts)
+		} else {
+			item.DownSQL = string(bts)
+		}
+	}
+	for _, version := range versions {
+		if err = m.Register(*migrationMap[version]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SplitStatements 按分号拆分sql 忽略引号,注释以及 $$ 包裹内的分号
+func SplitStatements(sql string) []string {
+	var (
+		list      []string
+		buf       strings.Builder
+		quote     byte
+		dollar    bool
+		lineCmt   bool
+		blockCmt  bool
+		bts       = []byte(sql)
+		flushStmt = func() {
+			if stmt := strings.TrimSpace(buf.String()); stmt != "" {
+				list = append(list, stmt)
+			}
+			buf.Reset()
+		}
+	)
+	for i := 0; i < len(bts); i++ {
+		c := bts[i]
+		var next byte
+		if i+1 < len(bts) {
+			next = bts[i+1]
+		}
+		switch {
+		case lineCmt:
+			if c == '\n' {
+				lineCmt = false
+				buf.WriteByte(c)
+			}
+			continue
+		case blockCmt:
+			if c == '*' && next == '/' {
+				blockCmt = false
+				i++
+			}
+			continue
+		case quote != 0:
+			buf.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case dollar:
+			buf.WriteByte(c)
+			if c == '$' && next == '$' {
+				buf.WriteByte(next)
+				dollar = false
+				i++
+			}
+			continue
+		}
+		switch {
+		case c == '-' && next == '-':
+			lineCmt = true
+			i++
+		case c == '/' && next == '*':
+			blockCmt = true
+			i++
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			buf.WriteByte(c)
+		case c == '$' && next == '$':
+			dollar = true
+			buf.WriteString("$$")
+			i++
+		case c == ';':
+			flushStmt()
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	flushStmt()
+	return list
+}
+
+func newSQLRecorder(l logger.Interface) *sqlRecorder {
+	return &sqlRecorder{Interface: l.LogMode(logger.Silent)}
+}
+func (r *sqlRecorder) LogMode(level logger.LogLevel) logger.Interface {
+	return r
+}
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (sql string, rowsAffected int64),
+	_ error) {
+	sql, _ := fc()
+	r.mu.Lock()
+	r.statements = append(r.statements, sql)
+	r.mu.Unlock()
+}
+func (r *sqlRecorder) Statements() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.statements...)
+}
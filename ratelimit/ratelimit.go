@@ -0,0 +1,204 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Algorithm
+const (
+	// TokenBucket 令牌桶 允许Burst大小的突发
+	TokenBucket Algorithm = iota
+	// SlidingWindow 滑动窗口计数 按上一窗口的计数加权估算
+	SlidingWindow
+)
+
+const (
+	defaultGCInterval = time.Minute
+)
+
+var (
+	ErrInvalidLimit = errors.New("invalid rate limit")
+)
+
+type (
+	Algorithm int
+	// Limit 每 Period 允许 Rate 个请求
+	Limit struct {
+		Algorithm Algorithm
+		Rate      int
+		Period    time.Duration
+		Burst     int // 令牌桶容量 为0时等于Rate
+	}
+	Result struct {
+		Allowed    bool
+		Limit      int
+		Remaining  int
+		Reset      time.Duration // 配额完全恢复所需时间
+		RetryAfter time.Duration // 被拒绝时 下次可请求的等待时间
+	}
+	// Store 限流状态存储 分布式场景可基于redis等实现
+	Store interface {
+		Allow(ctx context.Context, key string, limit Limit) (Result, error)
+	}
+	MemoryStore struct {
+		mu         sync.Mutex
+		buckets    map[string]*tokenBucket
+		windows    map[string]*slidingWindow
+		lastGC     time.Time
+		gcInterval time.Duration
+	}
+	tokenBucket struct {
+		tokens   float64
+		last     time.Time
+		idleTime time.Duration
+	}
+	slidingWindow struct {
+		start    time.Time
+		prev     int
+		curr     int
+		idleTime time.Duration
+	}
+)
+
+func PerSecond(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Second}
+}
+func PerMinute(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+func PerHour(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Hour}
+}
+func (l Limit) WithAlgorithm(algorithm Algorithm) Limit {
+	l.Algorithm = algorithm
+	return l
+}
+func (l Limit) WithBurst(burst int) Limit {
+	l.Burst = burst
+	return l
+}
+func (l Limit) Valid() bool {
+	return l.Rate > 0 && l.Period > 0 && l.Burst >= 0
+}
+func (l Limit) capacity() int {
+	if l.Algorithm == TokenBucket && l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:    make(map[string]*tokenBucket),
+		windows:    make(map[string]*slidingWindow),
+		lastGC:     time.Now(),
+		gcInterval: defaultGCInterval,
+	}
+}
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	if !limit.Valid() {
+		return Result{}, ErrInvalidLimit
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gc(now)
+	switch limit.Algorithm {
+	case SlidingWindow:
+		w, ok := s.windows[key]
+		if !ok {
+			w = &slidingWindow{start: now}
+			s.windows[key] = w
+		}
+		return w.allow(now, limit), nil
+	default:
+		b, ok := s.buckets[key]
+		if !ok {
+			b = &tokenBucket{tokens: float64(limit.capacity()), last: now}
+			s.buckets[key] = b
+		}
+		return b.allow(now, limit), nil
+	}
+}
+
+// gc 清理已完全恢复的状态 避免key无限增长
+func (s *MemoryStore) gc(now time.Time) {
+	if now.Sub(s.lastGC) < s.gcInterval {
+		return
+	}
+	s.lastGC = now
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > b.idleTime {
+			delete(s.buckets, key)
+		}
+	}
+	for key, w := range s.windows {
+		if now.Sub(w.start) > w.idleTime {
+			delete(s.windows, key)
+		}
+	}
+}
+
+func (b *tokenBucket) allow(now time.Time, limit Limit) Result {
+	capacity := float64(limit.capacity())
+	ratePerSec := float64(limit.Rate) / limit.Period.Seconds()
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*ratePerSec)
+	b.last = now
+	b.idleTime = secondsToDuration(capacity / ratePerSec)
+	res := Result{Limit: limit.capacity()}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.tokens) / ratePerSec)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = secondsToDuration((capacity - b.tokens) / ratePerSec)
+	return res
+}
+
+func (w *slidingWindow) allow(now time.Time, limit Limit) Result {
+	elapsed := now.Sub(w.start)
+	if elapsed >= limit.Period {
+		windows := elapsed / limit.Period
+		if windows == 1 {
+			w.prev = w.curr
+		} else {
+			w.prev = 0
+		}
+		w.curr = 0
+		w.start = w.start.Add(windows * limit.Period)
+		elapsed = now.Sub(w.start)
+	}
+	w.idleTime = 2 * limit.Period
+	weight := 1 - float64(elapsed)/float64(limit.Period)
+	estimated := float64(w.prev)*weight + float64(w.curr)
+	res := Result{
+		Limit: limit.Rate,
+		Reset: limit.Period - elapsed,
+	}
+	if estimated+1 <= float64(limit.Rate) {
+		w.curr++
+		res.Allowed = true
+		res.Remaining = int(float64(limit.Rate) - estimated - 1)
+		return res
+	}
+	// 等待上一窗口的权重衰减到足以放行一个请求 当前窗口已满时需等到下个窗口
+	res.RetryAfter = limit.Period - elapsed
+	if w.curr+1 <= limit.Rate && w.prev > 0 {
+		need := float64(w.prev) - float64(limit.Rate-w.curr-1)
+		wait := time.Duration(need/float64(w.prev)*float64(limit.Period)) - elapsed
+		if wait > 0 && wait < res.RetryAfter {
+			res.RetryAfter = wait
+		}
+	}
+	return res
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type step struct {
+	at         time.Duration // 相对起始时间
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration
+	checkRetry bool
+}
+
+func TestTokenBucket(t *testing.T) {
+	cases := []struct {
+		name  string
+		limit Limit
+		steps []step
+	}{
+		{
+			name:  "burst then refill",
+			limit: PerSecond(2),
+			steps: []step{
+				{at: 0, allowed: true, remaining: 1},
+				{at: 0, allowed: true, remaining: 0},
+				{at: 0, allowed: false, remaining: 0, retryAfter: 500 * time.Millisecond, checkRetry: true},
+				{at: 500 * time.Millisecond, allowed: true, remaining: 0},
+				{at: 2 * time.Second, allowed: true, remaining: 1},
+			},
+		},
+		{
+			name:  "custom burst",
+			limit: PerMinute(60).WithBurst(3),
+			steps: []step{
+				{at: 0, allowed: true, remaining: 2},
+				{at: 0, allowed: true, remaining: 1},
+				{at: 0, allowed: true, remaining: 0},
+				{at: 0, allowed: false, retryAfter: time.Second, checkRetry: true},
+				{at: time.Second, allowed: true, remaining: 0},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Now()
+			b := &tokenBucket{tokens: float64(tc.limit.capacity()), last: start}
+			for i, s := range tc.steps {
+				checkStep(t, i, b.allow(start.Add(s.at), tc.limit), s)
+			}
+		})
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limit := PerSecond(2).WithAlgorithm(SlidingWindow)
+	cases := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "window full",
+			steps: []step{
+				{at: 0, allowed: true, remaining: 1},
+				{at: 100 * time.Millisecond, allowed: true, remaining: 0},
+				{at: 200 * time.Millisecond, allowed: false, retryAfter: 800 * time.Millisecond, checkRetry: true},
+			},
+		},
+		{
+			name: "previous window weighted",
+			steps: []step{
+				{at: 0, allowed: true, remaining: 1},
+				{at: 0, allowed: true, remaining: 0},
+				// 上一窗口权重0.75 估算1.5 再放行一个会超限
+				{at: 1250 * time.Millisecond, allowed: false, retryAfter: 250 * time.Millisecond, checkRetry: true},
+				{at: 1500 * time.Millisecond, allowed: true, remaining: 0},
+			},
+		},
+		{
+			name: "idle windows reset",
+			steps: []step{
+				{at: 0, allowed: true, remaining: 1},
+				{at: 0, allowed: true, remaining: 0},
+				{at: 3 * time.Second, allowed: true, remaining: 1},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Now()
+			w := &slidingWindow{start: start}
+			for i, s := range tc.steps {
+				checkStep(t, i, w.allow(start.Add(s.at), limit), s)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if _, err := store.Allow(ctx, "k", Limit{}); err != ErrInvalidLimit {
+		t.Fatalf("invalid limit err = %v", err)
+	}
+	for _, limit := range []Limit{PerHour(1), PerHour(1).WithAlgorithm(SlidingWindow)} {
+		for i, want := range []bool{true, false} {
+			res, err := store.Allow(ctx, "user", limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Allowed != want {
+				t.Fatalf("algorithm %d request %d allowed = %v, want %v", limit.Algorithm, i, res.Allowed, want)
+			}
+		}
+		if res, _ := store.Allow(ctx, "other", limit); !res.Allowed {
+			t.Fatalf("algorithm %d keys share quota", limit.Algorithm)
+		}
+	}
+}
+
+func checkStep(t *testing.T, i int, res Result, s step) {
+	t.Helper()
+	if res.Allowed != s.allowed {
+		t.Fatalf("step %d allowed = %v, want %v", i, res.Allowed, s.allowed)
+	}
+	if s.allowed && res.Remaining != s.remaining {
+		t.Fatalf("step %d remaining = %d, want %d", i, res.Remaining, s.remaining)
+	}
+	if s.checkRetry && res.RetryAfter != s.retryAfter {
+		t.Fatalf("step %d retryAfter = %v, want %v", i, res.RetryAfter, s.retryAfter)
+	}
+}
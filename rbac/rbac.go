@@ -0,0 +1,284 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/real-web-world/bdk/auth"
+	"github.com/real-web-world/bdk/json"
+)
+
+const (
+	// Wildcard 单独使用时表示全部权限 作为最后一段时匹配该前缀下的全部权限 如 order:*
+	Wildcard = "*"
+	// PermSep 权限分段分隔符 如 order:edit
+	PermSep               = ":"
+	DefaultReloadInterval = 5 * time.Second
+	maxInheritDepth       = 16
+)
+
+var (
+	ErrUnknownRole   = errors.New("rbac unknown role")
+	ErrInheritCycle  = errors.New("rbac role inherit cycle")
+	defaultAuthz     = newEmptyAuthorizer()
+	defaultAuthzLock sync.RWMutex
+)
+
+type (
+	Role struct {
+		Permissions []string `json:"permissions"`
+		// Inherits 继承其他角色的全部权限
+		Inherits []string `json:"inherits,omitempty"`
+	}
+	// Policy 策略 可从json文件加载
+	//
+	//	{
+	//	  "roles": {
+	//	    "viewer": {"permissions": ["order:view"]},
+	//	    "editor": {"permissions": ["order:edit"], "inherits": ["viewer"]},
+	//	    "admin":  {"permissions": ["*"]}
+	//	  },
+	//	  "routes": {"POST /order/edit": ["order:edit"], "/order/list": ["order:view"]}
+	//	}
+	Policy struct {
+		Roles map[string]Role `json:"roles"`
+		// Routes 路由声明 key为 "METHOD 路由" 或 "路由" 路由为 gin 的 FullPath
+		Routes map[string][]string `json:"routes,omitempty"`
+	}
+	// Authorizer 持有当前策略 可并发使用 策略替换为原子操作
+	Authorizer struct {
+		policy atomic.Pointer[compiledPolicy]
+	}
+	compiledPolicy struct {
+		raw   *Policy
+		perms map[string][]string // 角色 -> 含继承的有效权限
+	}
+)
+
+// NewAuthorizer 继承关系有误(未知角色或循环继承)时返回错误
+func NewAuthorizer(policy *Policy) (*Authorizer, error) {
+	if policy == nil {
+		policy = &Policy{}
+	}
+	a := &Authorizer{}
+	if err := a.SetPolicy(policy); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+func newEmptyAuthorizer() *Authorizer {
+	a := &Authorizer{}
+	a.policy.Store(&compiledPolicy{raw: &Policy{}, perms: map[string][]string{}})
+	return a
+}
+
+// Default 默认授权器 App 的权限辅助函数在未指定授权器时使用
+func Default() *Authorizer {
+	defaultAuthzLock.RLock()
+	defer defaultAuthzLock.RUnlock()
+	return defaultAuthz
+}
+func SetDefault(a *Authorizer) {
+	defaultAuthzLock.Lock()
+	defaultAuthz = a
+	defaultAuthzLock.Unlock()
+}
+
+// LoadPolicyFile 从json文件加载策略
+func LoadPolicyFile(path string) (*Policy, error) {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err = json.Unmarshal(bts, policy); err != nil {
+		return nil, fmt.Errorf("rbac policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// SetPolicy 校验并替换策略 出错时保留原策略
+func (a *Authorizer) SetPolicy(policy *Policy) error {
+	compiled, err := compile(policy)
+	if err != nil {
+		return err
+	}
+	a.policy.Store(compiled)
+	return nil
+}
+func (a *Authorizer) Policy() *Policy {
+	return a.policy.Load().raw
+}
+
+// LoadFile 从文件加载策略并替换
+func (a *Authorizer) LoadFile(path string) error {
+	policy, err := LoadPolicyFile(path)
+	if err != nil {
+		return err
+	}
+	return a.SetPolicy(policy)
+}
+
+// WatchFile 定期检查文件修改时间 变化后重新加载 直到ctx结束
+// 加载失败时保留原策略 错误传给onErr(可为nil)
+func (a *Authorizer) WatchFile(ctx context.Context, path string, interval time.Duration,
+	onErr func(err error)) error {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err = a.LoadFile(path); err != nil {
+		return err
+	}
+	go func() {
+		modTime := info.ModTime()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			info, err := os.Stat(path)
+			if err == nil && info.ModTime().Equal(modTime) {
+				continue
+			}
+			if err == nil {
+				modTime = info.ModTime()
+				err = a.LoadFile(path)
+			}
+			if err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+	}()
+	return nil
+}
+
+// RolePermissions 角色含继承的有效权限 未知角色返回nil
+func (a *Authorizer) RolePermissions(role string) []string {
+	return a.policy.Load().perms[role]
+}
+
+// Permissions 调用方的有效权限 为全部角色权限与 Scopes 的并集 已排序去重
+func (a *Authorizer) Permissions(p *auth.Principal) []string {
+	if p == nil {
+		return nil
+	}
+	compiled := a.policy.Load()
+	set := make(map[string]struct{})
+	for _, role := range p.Roles {
+		for _, perm := range compiled.perms[role] {
+			set[perm] = struct{}{}
+		}
+	}
+	for _, scope := range p.Scopes {
+		set[scope] = struct{}{}
+	}
+	return sortedKeys(set)
+}
+
+// Allowed 调用方是否拥有全部 required 权限
+func (a *Authorizer) Allowed(p *auth.Principal, required ...string) bool {
+	granted := a.Permissions(p)
+	for _, perm := range required {
+		if !Granted(granted, perm) {
+			return false
+		}
+	}
+	return p != nil
+}
+
+// AllowedAny 调用方是否拥有任一 required 权限
+func (a *Authorizer) AllowedAny(p *auth.Principal, required ...string) bool {
+	granted := a.Permissions(p)
+	for _, perm := range required {
+		if Granted(granted, perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutePermissions 路由声明的权限 优先匹配 "METHOD 路由"
+func (a *Authorizer) RoutePermissions(method, fullPath string) ([]string, bool) {
+	routes := a.policy.Load().raw.Routes
+	if perms, ok := routes[method+" "+fullPath]; ok {
+		return perms, true
+	}
+	perms, ok := routes[fullPath]
+	return perms, ok
+}
+
+// Granted granted中是否有权限匹配required 支持通配符
+func Granted(granted []string, required string) bool {
+	for _, perm := range granted {
+		if Match(perm, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match 权限pattern是否覆盖required 如 order:* 覆盖 order:edit 与 order:item:edit
+func Match(pattern, required string) bool {
+	if pattern == Wildcard || pattern == required {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, PermSep+Wildcard)
+	return ok && strings.HasPrefix(required, prefix+PermSep)
+}
+
+func compile(policy *Policy) (*compiledPolicy, error) {
+	compiled := &compiledPolicy{
+		raw:   policy,
+		perms: make(map[string][]string, len(policy.Roles)),
+	}
+	for name := range policy.Roles {
+		set := make(map[string]struct{})
+		if err := collectPerms(policy, name, set, 0, map[string]bool{}); err != nil {
+			return nil, err
+		}
+		compiled.perms[name] = sortedKeys(set)
+	}
+	return compiled, nil
+}
+func collectPerms(policy *Policy, name string, set map[string]struct{}, depth int, visiting map[string]bool) error {
+	role, ok := policy.Roles[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownRole, name)
+	}
+	if visiting[name] || depth > maxInheritDepth {
+		return fmt.Errorf("%w: %s", ErrInheritCycle, name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+	for _, perm := range role.Permissions {
+		set[perm] = struct{}{}
+	}
+	for _, parent := range role.Inherits {
+		if err := collectPerms(policy, parent, set, depth+1, visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func sortedKeys(set map[string]struct{}) []string {
+	list := make([]string, 0, len(set))
+	for key := range set {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	return list
+}
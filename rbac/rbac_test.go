@@ -0,0 +1,317 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/real-web-world/bdk/auth"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		required string
+		want     bool
+	}{
+		{pattern: "*", required: "order:edit", want: true},
+		{pattern: "order:edit", required: "order:edit", want: true},
+		{pattern: "order:edit", required: "order:view"},
+		{pattern: "order:*", required: "order:edit", want: true},
+		{pattern: "order:*", required: "order:item:edit", want: true},
+		{pattern: "order:*", required: "order"},
+		{pattern: "order:*", required: "orders:edit"},
+		{pattern: "order:item:*", required: "order:edit"},
+		{pattern: "order*", required: "orders:edit"},
+		{pattern: "", required: "order:edit"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.pattern+" "+tc.required, func(t *testing.T) {
+			if got := Match(tc.pattern, tc.required); got != tc.want {
+				t.Fatalf("Match(%q, %q) = %v, want %v", tc.pattern, tc.required, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	cases := []struct {
+		name    string
+		roles   map[string]Role
+		role    string
+		want    []string
+		wantErr error
+	}{
+		{name: "nil policy"},
+		{
+			name: "inherit",
+			roles: map[string]Role{
+				"viewer": {Permissions: []string{"order:view"}},
+				"editor": {Permissions: []string{"order:edit"}, Inherits: []string{"viewer"}},
+				"admin":  {Permissions: []string{"user:edit"}, Inherits: []string{"editor"}},
+			},
+			role: "admin",
+			want: []string{"order:edit", "order:view", "user:edit"},
+		},
+		{
+			name: "diamond inherit",
+			roles: map[string]Role{
+				"base":  {Permissions: []string{"a"}},
+				"left":  {Permissions: []string{"b"}, Inherits: []string{"base"}},
+				"right": {Permissions: []string{"c"}, Inherits: []string{"base"}},
+				"top":   {Inherits: []string{"left", "right"}},
+			},
+			role: "top",
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name:    "unknown role",
+			roles:   map[string]Role{"editor": {Inherits: []string{"viewer"}}},
+			wantErr: ErrUnknownRole,
+		},
+		{
+			name: "cycle",
+			roles: map[string]Role{
+				"a": {Permissions: []string{"x"}, Inherits: []string{"b"}},
+				"b": {Permissions: []string{"y"}, Inherits: []string{"a"}},
+			},
+			wantErr: ErrInheritCycle,
+		},
+		{
+			name:    "self inherit",
+			roles:   map[string]Role{"a": {Inherits: []string{"a"}}},
+			wantErr: ErrInheritCycle,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var policy *Policy
+			if tc.roles != nil {
+				policy = &Policy{Roles: tc.roles}
+			}
+			a, err := NewAuthorizer(policy)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if err != nil {
+				if a != nil {
+					t.Fatal("authorizer returned with error")
+				}
+				return
+			}
+			if got := a.RolePermissions(tc.role); !slices.Equal(got, tc.want) {
+				t.Fatalf("RolePermissions(%q) = %v, want %v", tc.role, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetPolicyKeepsOldOnError(t *testing.T) {
+	a, err := NewAuthorizer(&Policy{Roles: map[string]Role{"viewer": {Permissions: []string{"order:view"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := a.Policy()
+	err = a.SetPolicy(&Policy{Roles: map[string]Role{"a": {Inherits: []string{"a"}}}})
+	if !errors.Is(err, ErrInheritCycle) {
+		t.Fatalf("err = %v, want %v", err, ErrInheritCycle)
+	}
+	if a.Policy() != old {
+		t.Fatal("policy replaced on error")
+	}
+	if got := a.RolePermissions("viewer"); !slices.Equal(got, []string{"order:view"}) {
+		t.Fatalf("viewer permissions = %v", got)
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	a, err := NewAuthorizer(&Policy{Roles: map[string]Role{
+		"viewer": {Permissions: []string{"order:view"}},
+		"editor": {Permissions: []string{"order:edit"}, Inherits: []string{"viewer"}},
+		"admin":  {Permissions: []string{"*"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name      string
+		principal *auth.Principal
+		required  []string
+		perms     []string
+		all       bool
+		any       bool
+	}{
+		{name: "nil principal", required: []string{"order:view"}},
+		{name: "nil principal no required"},
+		{
+			name:      "no required",
+			principal: &auth.Principal{ID: "1"},
+			all:       true,
+		},
+		{
+			name:      "inherit",
+			principal: &auth.Principal{ID: "1", Roles: []string{"editor"}},
+			required:  []string{"order:view", "order:edit"},
+			perms:     []string{"order:edit", "order:view"},
+			all:       true,
+			any:       true,
+		},
+		{
+			name:      "partial",
+			principal: &auth.Principal{ID: "1", Roles: []string{"viewer"}},
+			required:  []string{"order:view", "order:edit"},
+			perms:     []string{"order:view"},
+			any:       true,
+		},
+		{
+			name:      "scope merge",
+			principal: &auth.Principal{ID: "1", Roles: []string{"viewer"}, Scopes: []string{"order:edit", "order:view"}},
+			required:  []string{"order:view", "order:edit"},
+			perms:     []string{"order:edit", "order:view"},
+			all:       true,
+			any:       true,
+		},
+		{
+			name:      "scope wildcard",
+			principal: &auth.Principal{ID: "1", Scopes: []string{"order:*"}},
+			required:  []string{"order:item:edit", "user:edit"},
+			perms:     []string{"order:*"},
+			any:       true,
+		},
+		{
+			name:      "admin wildcard",
+			principal: &auth.Principal{ID: "1", Roles: []string{"admin"}},
+			required:  []string{"order:edit", "user:edit"},
+			perms:     []string{"*"},
+			all:       true,
+			any:       true,
+		},
+		{
+			name:      "unknown role",
+			principal: &auth.Principal{ID: "1", Roles: []string{"ghost"}},
+			required:  []string{"order:view"},
+			perms:     []string{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := a.Permissions(tc.principal); !slices.Equal(got, tc.perms) {
+				t.Fatalf("Permissions = %v, want %v", got, tc.perms)
+			}
+			if got := a.Allowed(tc.principal, tc.required...); got != tc.all {
+				t.Fatalf("Allowed = %v, want %v", got, tc.all)
+			}
+			if got := a.AllowedAny(tc.principal, tc.required...); got != tc.any {
+				t.Fatalf("AllowedAny = %v, want %v", got, tc.any)
+			}
+		})
+	}
+}
+
+func TestRoutePermissions(t *testing.T) {
+	a, err := NewAuthorizer(&Policy{Routes: map[string][]string{
+		"POST /order/:id": {"order:edit"},
+		"/order/:id":      {"order:view"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		method string
+		path   string
+		want   []string
+		ok     bool
+	}{
+		{method: "POST", path: "/order/:id", want: []string{"order:edit"}, ok: true},
+		{method: "GET", path: "/order/:id", want: []string{"order:view"}, ok: true},
+		{method: "GET", path: "/order/1"},
+		{method: "GET", path: "/user/:id"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			got, ok := a.RoutePermissions(tc.method, tc.path)
+			if ok != tc.ok || !slices.Equal(got, tc.want) {
+				t.Fatalf("RoutePermissions = %v %v, want %v %v", got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := time.Now().Add(-time.Hour)
+	write(`{"roles":{"viewer":{"permissions":["order:view"]}}}`, base)
+	a, err := NewAuthorizer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 8)
+	if err = a.WatchFile(ctx, path, 10*time.Millisecond, func(err error) { errCh <- err }); err != nil {
+		t.Fatal(err)
+	}
+	viewer := &auth.Principal{ID: "1", Roles: []string{"viewer"}}
+	if !a.Allowed(viewer, "order:view") || a.Allowed(viewer, "order:edit") {
+		t.Fatal("initial policy not loaded")
+	}
+	waitFor := func(cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal("timeout waiting for reload")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	write(`{"roles":{"viewer":{"permissions":["order:view","order:edit"]}}}`, base.Add(time.Minute))
+	waitFor(func() bool { return a.Allowed(viewer, "order:edit") })
+
+	// 无效策略不生效 错误交给onErr
+	write(`{"roles":{"viewer":{"inherits":["viewer"]}}}`, base.Add(2*time.Minute))
+	select {
+	case err = <-errCh:
+		if !errors.Is(err, ErrInheritCycle) {
+			t.Fatalf("onErr = %v, want %v", err, ErrInheritCycle)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("onErr not called")
+	}
+	if !a.Allowed(viewer, "order:edit") {
+		t.Fatal("policy replaced by invalid file")
+	}
+
+	write(`{"roles":{"viewer":{"permissions":["order:view"]}}}`, base.Add(3*time.Minute))
+	waitFor(func() bool { return !a.Allowed(viewer, "order:edit") })
+}
+
+func TestWatchFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(`{"roles":{"a":{"inherits":["b"]}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewAuthorizer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = a.WatchFile(context.Background(), path, time.Hour, nil); !errors.Is(err, ErrUnknownRole) {
+		t.Fatalf("err = %v, want %v", err, ErrUnknownRole)
+	}
+	if err = a.WatchFile(context.Background(), filepath.Join(t.TempDir(), "missing.json"), time.Hour, nil); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("err = %v, want %v", err, os.ErrNotExist)
+	}
+}
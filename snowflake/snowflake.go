@@ -0,0 +1,202 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/real-web-world/bdk"
+)
+
+// id 组成: 1位符号位 + 41位毫秒时间戳 + 10位节点id + 12位序列号
+const (
+	NodeBits     = 10
+	SequenceBits = 12
+	MaxNodeID    = 1<<NodeBits - 1
+	maxSequence  = 1<<SequenceBits - 1
+	timeShift    = NodeBits + SequenceBits
+	nodeShift    = SequenceBits
+	// DefaultMaxBackward 时钟回拨在该范围内时等待追上 超过则返回错误
+	DefaultMaxBackward = 10 * time.Millisecond
+	// EnvNodeID 指定节点id的环境变量 部署多个实例时应为每个实例分配不同的值
+	EnvNodeID = "BDK_SNOWFLAKE_NODE_ID"
+)
+
+var (
+	// DefaultEpoch 2024-01-01 00:00:00 UTC
+	DefaultEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ErrInvalidNodeID  = errors.New("snowflake node id out of range")
+	ErrClockBackwards = errors.New("snowflake clock moved backwards")
+	ErrTimeOverflow   = errors.New("snowflake timestamp overflow")
+	ErrNoMac          = errors.New("snowflake no usable mac address")
+)
+
+var (
+	defaultGen     *Generator
+	defaultGenErr  error
+	defaultGenOnce sync.Once
+)
+
+type (
+	Option    func(g *Generator)
+	Generator struct {
+		mu          sync.Mutex
+		epoch       int64 // ms
+		nodeID      int64
+		maxBackward time.Duration
+		lastTs      int64
+		sequence    int64
+	}
+)
+
+func WithEpoch(epoch time.Time) Option {
+	return func(g *Generator) {
+		g.epoch = epoch.UnixMilli()
+	}
+}
+func WithMaxBackward(d time.Duration) Option {
+	return func(g *Generator) {
+		g.maxBackward = d
+	}
+}
+
+// NewGenerator 使用指定的节点id创建生成器
+func NewGenerator(nodeID int64, opts ...Option) (*Generator, error) {
+	if nodeID < 0 || nodeID > MaxNodeID {
+		return nil, ErrInvalidNodeID
+	}
+	g := &Generator{
+		epoch:       DefaultEpoch.UnixMilli(),
+		nodeID:      nodeID,
+		maxBackward: DefaultMaxBackward,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g, nil
+}
+
+// NewMacGenerator 节点id由本机mac地址计算得出
+func NewMacGenerator(opts ...Option) (*Generator, error) {
+	nodeID, err := MacNodeID()
+	if err != nil {
+		return nil, err
+	}
+	return NewGenerator(nodeID, opts...)
+}
+
+// NewEnvGenerator 节点id取自 EnvNodeID 未设置时退化为 NewMacGenerator
+func NewEnvGenerator(opts ...Option) (*Generator, error) {
+	nodeID, ok, err := EnvNodeIDValue()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return NewMacGenerator(opts...)
+	}
+	return NewGenerator(nodeID, opts...)
+}
+
+// Default 默认生成器 未通过 SetDefault 设置时由 NewEnvGenerator 创建
+// 无法确定节点id时返回错误 而不是退化为可能重复的节点id
+func Default() (*Generator, error) {
+	defaultGenOnce.Do(func() {
+		g, err := NewEnvGenerator()
+		if err != nil {
+			defaultGenErr = fmt.Errorf("snowflake: %w, set %s to assign a node id", err, EnvNodeID)
+			return
+		}
+		defaultGen = g
+	})
+	return defaultGen, defaultGenErr
+}
+
+// SetDefault 替换默认生成器 需在首次生成id之前调用
+func SetDefault(g *Generator) {
+	defaultGenOnce.Do(func() {})
+	defaultGen, defaultGenErr = g, nil
+}
+
+// NextID 使用默认生成器生成id
+func NextID() (int64, error) {
+	g, err := Default()
+	if err != nil {
+		return 0, err
+	}
+	return g.NextID()
+}
+
+// MacNodeID 将mac地址散列到节点id范围内 不同机器仍可能冲突 实例较多时应通过 EnvNodeID 分配
+// 容器中的网卡通常为本地管理地址 会被 bdk.GetMac 跳过 此时返回 ErrNoMac
+func MacNodeID() (int64, error) {
+	mac := bdk.GetMac()
+	if mac == 0 {
+		return 0, ErrNoMac
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strconv.FormatUint(mac, 16)))
+	return int64(h.Sum32() % (MaxNodeID + 1)), nil
+}
+
+// EnvNodeIDValue 读取 EnvNodeID 未设置时ok为false
+func EnvNodeIDValue() (nodeID int64, ok bool, err error) {
+	val := strings.TrimSpace(os.Getenv(EnvNodeID))
+	if val == "" {
+		return 0, false, nil
+	}
+	nodeID, err = strconv.ParseInt(val, 10, 64)
+	if err != nil || nodeID < 0 || nodeID > MaxNodeID {
+		return 0, false, ErrInvalidNodeID
+	}
+	return nodeID, true, nil
+}
+
+func (g *Generator) NodeID() int64 {
+	return g.nodeID
+}
+func (g *Generator) NextID() (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ts := time.Now().UnixMilli()
+	if ts < g.lastTs {
+		backward := time.Duration(g.lastTs-ts) * time.Millisecond
+		if backward > g.maxBackward {
+			return 0, ErrClockBackwards
+		}
+		time.Sleep(backward)
+		if ts = time.Now().UnixMilli(); ts < g.lastTs {
+			return 0, ErrClockBackwards
+		}
+	}
+	if ts == g.lastTs {
+		g.sequence = (g.sequence + 1) & maxSequence
+		if g.sequence == 0 {
+			for ts <= g.lastTs {
+				time.Sleep(100 * time.Microsecond)
+				ts = time.Now().UnixMilli()
+			}
+		}
+	} else {
+		g.sequence = 0
+	}
+	elapsed := ts - g.epoch
+	if elapsed < 0 || elapsed>>(63-timeShift) != 0 {
+		return 0, ErrTimeOverflow
+	}
+	g.lastTs = ts
+	return elapsed<<timeShift | g.nodeID<<nodeShift | g.sequence, nil
+}
+
+// Parse 解析id中的时间,节点id与序列号
+func (g *Generator) Parse(id int64) (t time.Time, nodeID int64, sequence int64) {
+	t = time.UnixMilli(id>>timeShift + g.epoch)
+	nodeID = id >> nodeShift & MaxNodeID
+	sequence = id & maxSequence
+	return
+}
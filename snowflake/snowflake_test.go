@@ -0,0 +1,128 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewGenerator(t *testing.T) {
+	cases := []struct {
+		nodeID  int64
+		wantErr error
+	}{
+		{nodeID: 0},
+		{nodeID: MaxNodeID},
+		{nodeID: -1, wantErr: ErrInvalidNodeID},
+		{nodeID: MaxNodeID + 1, wantErr: ErrInvalidNodeID},
+	}
+	for _, tc := range cases {
+		if _, err := NewGenerator(tc.nodeID); !errors.Is(err, tc.wantErr) {
+			t.Errorf("NewGenerator(%d) err = %v, want %v", tc.nodeID, err, tc.wantErr)
+		}
+	}
+}
+
+func TestNextIDUniqueAndParse(t *testing.T) {
+	g, err := NewGenerator(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const workers, perWorker = 8, 2000
+	ids := make(chan int64, workers*perWorker)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWorker {
+				id, err := g.NextID()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	seen := make(map[int64]struct{}, workers*perWorker)
+	for id := range ids {
+		if _, ok := seen[id]; ok {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = struct{}{}
+		ts, nodeID, _ := g.Parse(id)
+		if nodeID != 7 {
+			t.Fatalf("node id = %d", nodeID)
+		}
+		if time.Since(ts) > time.Minute || time.Until(ts) > time.Second {
+			t.Fatalf("parsed time %v out of range", ts)
+		}
+	}
+}
+
+func TestEpochOverflow(t *testing.T) {
+	g, _ := NewGenerator(1, WithEpoch(time.Now().Add(time.Hour)))
+	if _, err := g.NextID(); !errors.Is(err, ErrTimeOverflow) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestEnvNodeIDValue(t *testing.T) {
+	cases := []struct {
+		val     string
+		want    int64
+		ok      bool
+		wantErr error
+	}{
+		{val: ""},
+		{val: "12", want: 12, ok: true},
+		{val: " 1023 ", want: 1023, ok: true},
+		{val: "1024", wantErr: ErrInvalidNodeID},
+		{val: "-1", wantErr: ErrInvalidNodeID},
+		{val: "abc", wantErr: ErrInvalidNodeID},
+	}
+	for _, tc := range cases {
+		t.Setenv(EnvNodeID, tc.val)
+		got, ok, err := EnvNodeIDValue()
+		if got != tc.want || ok != tc.ok || !errors.Is(err, tc.wantErr) {
+			t.Errorf("%q: got (%d, %v, %v), want (%d, %v, %v)", tc.val, got, ok, err, tc.want, tc.ok, tc.wantErr)
+		}
+	}
+}
+
+func TestNewEnvGenerator(t *testing.T) {
+	t.Setenv(EnvNodeID, "42")
+	g, err := NewEnvGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NodeID() != 42 {
+		t.Fatalf("node id = %d", g.NodeID())
+	}
+}
+
+func TestDefault(t *testing.T) {
+	t.Cleanup(func() {
+		defaultGenOnce, defaultGen, defaultGenErr = sync.Once{}, nil, nil
+	})
+	defaultGenOnce = sync.Once{}
+	t.Setenv(EnvNodeID, "abc")
+	if _, err := Default(); !errors.Is(err, ErrInvalidNodeID) {
+		t.Fatalf("Default() err = %v, want %v", err, ErrInvalidNodeID)
+	}
+	if _, err := NextID(); !errors.Is(err, ErrInvalidNodeID) {
+		t.Fatalf("NextID() err = %v, want %v", err, ErrInvalidNodeID)
+	}
+	g, err := NewGenerator(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDefault(g)
+	if _, err = NextID(); err != nil {
+		t.Fatalf("NextID() after SetDefault err = %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	gormSpanKey      = "bdk:tracing:span"
+	gormCallbackName = "bdk:tracing"
+)
+
+var _ gorm.Plugin = (*GormPlugin)(nil)
+
+type (
+	// GormPlugin 为每次数据库操作创建子span 父span取自 db.WithContext 传入的上下文
+	// fastcurd 的查询均使用模型的上下文 注册后即可关联到请求span
+	//
+	//	db.Use(tracing.NewGormPlugin())
+	GormPlugin struct {
+		// WithVars 为true时记录带参数的sql 参数可能包含敏感数据 默认只记录占位符
+		WithVars bool
+		// DBSystem 数据库类型 如 mysql postgresql 为空时取 Dialector.Name()
+		DBSystem string
+		inst     Instrumentation
+	}
+)
+
+func NewGormPlugin(opts ...Option) *GormPlugin {
+	return &GormPlugin{inst: NewInstrumentation(opts...)}
+}
+func (p *GormPlugin) Name() string {
+	return gormCallbackName
+}
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	before, after := gormCallbackName+":before_", gormCallbackName+":after_"
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register(before+"create", p.before("create")),
+		cb.Create().After("gorm:create").Register(after+"create", p.after),
+		cb.Query().Before("gorm:query").Register(before+"query", p.before("query")),
+		cb.Query().After("gorm:query").Register(after+"query", p.after),
+		cb.Update().Before("gorm:update").Register(before+"update", p.before("update")),
+		cb.Update().After("gorm:update").Register(after+"update", p.after),
+		cb.Delete().Before("gorm:delete").Register(before+"delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register(after+"delete", p.after),
+		cb.Row().Before("gorm:row").Register(before+"row", p.before("row")),
+		cb.Row().After("gorm:row").Register(after+"row", p.after),
+		cb.Raw().Before("gorm:raw").Register(before+"raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register(after+"raw", p.after),
+	)
+}
+
+func (p *GormPlugin) before(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+			// 没有父span时不创建 避免后台任务产生大量孤立的根span
+			return
+		}
+		attrs := []attribute.KeyValue{
+			semconv.DBOperationName(operation),
+		}
+		if system := p.dbSystem(db); system != "" {
+			attrs = append(attrs, semconv.DBSystemNameKey.String(system))
+		}
+		if db.Statement.Table != "" {
+			attrs = append(attrs, semconv.DBCollectionName(db.Statement.Table))
+		}
+		// 不替换 Statement.Context 同一事务中的后续语句仍以请求span为父span
+		_, span := p.inst.Tracer().Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+		)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+func (p *GormPlugin) after(db *gorm.DB) {
+	val, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := val.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+	sql := db.Statement.SQL.String()
+	if p.WithVars && sql != "" {
+		sql = db.Dialector.Explain(sql, db.Statement.Vars...)
+	}
+	if sql != "" {
+		span.SetAttributes(semconv.DBQueryText(sql))
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", db.RowsAffected))
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
+func (p *GormPlugin) dbSystem(db *gorm.DB) string {
+	if p.DBSystem != "" {
+		return p.DBSystem
+	}
+	if db.Dialector != nil {
+		return db.Dialector.Name()
+	}
+	return ""
+}
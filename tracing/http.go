@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/real-web-world/bdk"
+)
+
+type (
+	// Transport 调用下游服务时创建client span 并通过 traceparent 传播上下文
+	// 可与 bdk.ReqIDTransport 组合使用
+	Transport struct {
+		Base http.RoundTripper
+		inst Instrumentation
+	}
+)
+
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	return &Transport{Base: base, inst: NewInstrumentation(opts...)}
+}
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx, span := t.inst.Tracer().Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLFull(req.URL.Redacted()),
+			semconv.ServerAddress(req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+	if reqID := bdk.GetReqID(ctx); reqID != "" {
+		span.SetAttributes(AttrReqID.String(reqID))
+	}
+	req = req.Clone(ctx)
+	t.inst.Propagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	// Option 为 bdk 的埋点指定 TracerProvider 与传播器 未指定时使用全局设置
+	Option func(inst *Instrumentation)
+	// Instrumentation 埋点使用的 Tracer 与传播器
+	Instrumentation struct {
+		tracerProvider trace.TracerProvider
+		propagator     propagation.TextMapPropagator
+	}
+)
+
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(inst *Instrumentation) {
+		inst.tracerProvider = tp
+	}
+}
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(inst *Instrumentation) {
+		inst.propagator = p
+	}
+}
+
+func NewInstrumentation(opts ...Option) Instrumentation {
+	inst := Instrumentation{}
+	for _, opt := range opts {
+		opt(&inst)
+	}
+	return inst
+}
+
+// Tracer 未指定 TracerProvider 时每次取全局设置 以便 Setup 可在埋点创建之后调用
+func (inst Instrumentation) Tracer() trace.Tracer {
+	if inst.tracerProvider != nil {
+		return inst.tracerProvider.Tracer(InstrumentationName)
+	}
+	return Tracer()
+}
+func (inst Instrumentation) Propagator() propagation.TextMapPropagator {
+	if inst.propagator != nil {
+		return inst.propagator
+	}
+	return otel.GetTextMapPropagator()
+}
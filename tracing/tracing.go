@@ -0,0 +1,171 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Exporter
+const (
+	ExporterOTLP   Exporter = "otlp"
+	ExporterStdout Exporter = "stdout"
+	// ExporterNone 只传播上下文 不导出span
+	ExporterNone Exporter = "none"
+)
+
+const (
+	InstrumentationName = "github.com/real-web-world/bdk"
+	// AttrReqID span上的请求id 用于与日志中的 bdk.gin.reqID 关联
+	AttrReqID = attribute.Key("bdk.req_id")
+	// ZapKeyTraceID ZapKeySpanID 注入zap日志的字段名
+	ZapKeyTraceID = "trace_id"
+	ZapKeySpanID  = "span_id"
+)
+
+var (
+	ErrUnknownExporter = errors.New("tracing unknown exporter")
+)
+
+type (
+	Exporter string
+	Config   struct {
+		ServiceName    string
+		ServiceVersion string
+		Exporter       Exporter
+		// OTLPEndpoint host:port 为空时使用 OTEL_EXPORTER_OTLP_ENDPOINT 环境变量或 localhost:4318
+		OTLPEndpoint string
+		OTLPInsecure bool
+		OTLPHeaders  map[string]string
+		// Writer stdout导出的目标 默认 os.Stdout
+		Writer io.Writer
+		// SampleRatio 根span的采样率 0-1 为0时全部采样 上游已采样的请求始终跟随上游
+		SampleRatio float64
+	}
+	// Provider 持有全局 TracerProvider 进程退出前需调用 Shutdown 导出剩余span
+	// 测试时使用 tracingtest 包 不修改全局设置
+	Provider struct {
+		*sdktrace.TracerProvider
+	}
+)
+
+// Setup 创建 TracerProvider 并设置为全局 同时使用 W3C traceparent 与 baggage 传播
+func Setup(ctx context.Context, cfg Config) (*Provider, error) {
+	p := &Provider{}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(newResource(cfg)),
+	}
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		opts = append(opts, sdktrace.WithSampler(
+			sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))))
+	}
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		clientOpts := make([]otlptracehttp.Option, 0, 3)
+		if cfg.OTLPEndpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.OTLPHeaders) > 0 {
+			clientOpts = append(clientOpts, otlptracehttp.WithHeaders(cfg.OTLPHeaders))
+		}
+		exp, err := otlptracehttp.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case ExporterStdout:
+		w := cfg.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithSyncer(exp))
+	case ExporterNone, "":
+	default:
+		return nil, ErrUnknownExporter
+	}
+	p.TracerProvider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(p.TracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	return p, nil
+}
+
+// Shutdown 导出剩余span ctx无超时时默认等待5秒
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+	}
+	return p.TracerProvider.Shutdown(ctx)
+}
+
+func newResource(cfg Config) *resource.Resource {
+	attrs := make([]attribute.KeyValue, 0, 2)
+	if cfg.ServiceName != "" {
+		attrs = append(attrs, semconv.ServiceName(cfg.ServiceName))
+	}
+	if cfg.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(cfg.ServiceVersion))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, attrs...))
+	if err != nil {
+		return resource.Default()
+	}
+	return res
+}
+
+// Tracer bdk内部使用的tracer 取自全局 TracerProvider
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// TraceID 上下文中的trace id 没有有效span时返回空
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// ZapFields 上下文中span的 trace_id 与 span_id
+func ZapFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String(ZapKeyTraceID, sc.TraceID().String()),
+		zap.String(ZapKeySpanID, sc.SpanID().String()),
+	}
+}
+
+// Logger 返回带 trace_id span_id 字段的logger
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	fields := ZapFields(ctx)
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
@@ -0,0 +1,158 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/real-web-world/bdk/tracing"
+	"github.com/real-web-world/bdk/tracing/tracingtest"
+)
+
+func findSpan(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, span := range spans {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+func attrValue(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTransport(t *testing.T) {
+	cases := []struct {
+		name       string
+		status     int
+		wantStatus int64
+	}{
+		{name: "ok", status: http.StatusOK, wantStatus: http.StatusOK},
+		{name: "server error", status: http.StatusBadGateway, wantStatus: http.StatusBadGateway},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := tracingtest.New()
+			var gotParent string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotParent = r.Header.Get("traceparent")
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+			ctx, parent := rec.Provider.Tracer("test").Start(context.Background(), "parent")
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			client := &http.Client{Transport: tracing.NewTransport(nil, rec.Options()...)}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			parent.End()
+			span, ok := findSpan(rec.Spans(), "HTTP GET")
+			if !ok {
+				t.Fatal("client span not recorded")
+			}
+			if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+				t.Fatal("client span not parented")
+			}
+			if want := "00-" + span.SpanContext.TraceID().String() + "-" + span.SpanContext.SpanID().String() + "-01"; gotParent != want {
+				t.Fatalf("traceparent = %q, want %q", gotParent, want)
+			}
+			if v, _ := attrValue(span, "http.response.status_code"); v.AsInt64() != tc.wantStatus {
+				t.Fatalf("status attr = %v", v.AsInt64())
+			}
+		})
+	}
+}
+
+func TestGormPlugin(t *testing.T) {
+	rec := tracingtest.New()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(tracing.NewGormPlugin(rec.Options()...)); err != nil {
+		t.Fatal(err)
+	}
+	type item struct {
+		ID   int64
+		Name string
+	}
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Spans()) != 0 {
+		t.Fatal("span created without parent")
+	}
+	ctx, parent := rec.Provider.Tracer("test").Start(context.Background(), "parent")
+	db.WithContext(ctx).Create(&item{Name: "a"})
+	db.WithContext(ctx).Where("id = ?", -1).First(&item{})
+	parent.End()
+	cases := []struct {
+		name  string
+		table string
+		error bool
+	}{
+		{name: "gorm.create", table: "items"},
+		{name: "gorm.query", table: "items"},
+	}
+	for _, tc := range cases {
+		span, ok := findSpan(rec.Spans(), tc.name)
+		if !ok {
+			t.Fatalf("%s not recorded", tc.name)
+		}
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("%s not parented", tc.name)
+		}
+		if v, _ := attrValue(span, "db.collection.name"); v.AsString() != tc.table {
+			t.Fatalf("%s table = %q", tc.name, v.AsString())
+		}
+		if len(span.Events) != 0 {
+			t.Fatalf("%s recorded error events: record not found must be ignored", tc.name)
+		}
+	}
+}
+
+func TestZapFields(t *testing.T) {
+	if fields := tracing.ZapFields(context.Background()); fields != nil {
+		t.Fatalf("fields without span = %v", fields)
+	}
+	rec := tracingtest.New()
+	ctx, span := rec.Provider.Tracer("test").Start(context.Background(), "s")
+	defer span.End()
+	fields := tracing.ZapFields(ctx)
+	if len(fields) != 2 || fields[0].String != span.SpanContext().TraceID().String() {
+		t.Fatalf("fields = %v", fields)
+	}
+	if tracing.TraceID(ctx) != span.SpanContext().TraceID().String() {
+		t.Fatal("trace id mismatch")
+	}
+}
+
+func TestRecorderInstall(t *testing.T) {
+	rec := tracingtest.New()
+	prev := otel.GetTracerProvider()
+	restore := rec.Install()
+	_, span := tracing.Tracer().Start(context.Background(), "global")
+	span.End()
+	restore()
+	if _, ok := findSpan(rec.Spans(), "global"); !ok {
+		t.Fatal("global tracer not recorded")
+	}
+	if otel.GetTracerProvider() != prev {
+		t.Fatal("global provider not restored")
+	}
+}
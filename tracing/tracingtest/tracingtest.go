@@ -0,0 +1,62 @@
+// Package tracingtest 将 bdk 埋点产生的span记录到内存 用于测试
+//
+//	rec := tracingtest.New()
+//	r.Use(middleware.TracingWith(rec.Options()...))
+//	db.Use(tracing.NewGormPlugin(rec.Options()...))
+//	...
+//	spans := rec.Spans()
+//
+// 被测代码使用全局设置时 可通过 Install 临时替换全局 TracerProvider 与传播器
+package tracingtest
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/real-web-world/bdk/tracing"
+)
+
+type (
+	// Recorder 同步导出到内存 span结束后即可通过 Spans 取得
+	Recorder struct {
+		Provider   *sdktrace.TracerProvider
+		Exporter   *tracetest.InMemoryExporter
+		Propagator propagation.TextMapPropagator
+	}
+)
+
+func New() *Recorder {
+	exporter := tracetest.NewInMemoryExporter()
+	return &Recorder{
+		Provider:   sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+		Exporter:   exporter,
+		Propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+}
+
+// Options 传给 bdk 各埋点 不修改全局设置 可并行测试
+func (r *Recorder) Options() []tracing.Option {
+	return []tracing.Option{
+		tracing.WithTracerProvider(r.Provider),
+		tracing.WithPropagator(r.Propagator),
+	}
+}
+
+// Install 设置为全局 TracerProvider 与传播器 返回恢复原设置的函数
+func (r *Recorder) Install() (restore func()) {
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(r.Provider)
+	otel.SetTextMapPropagator(r.Propagator)
+	return func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	}
+}
+func (r *Recorder) Spans() tracetest.SpanStubs {
+	return r.Exporter.GetSpans()
+}
+func (r *Recorder) Reset() {
+	r.Exporter.Reset()
+}
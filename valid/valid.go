@@ -3,10 +3,14 @@ package valid
 import (
 	"reflect"
 	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/gin-gonic/gin/binding"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+
+	"github.com/real-web-world/bdk/i18n"
 )
 
 type (
@@ -15,12 +19,21 @@ type (
 		once     sync.Once
 		validate *validator.Validate
 	}
+	// FieldError 单个字段的校验失败信息 Field 为按json tag拼接的字段路径
+	FieldError struct {
+		Field string `json:"field" example:"items[0].phone"`
+		Rule  string `json:"rule" example:"phone"`
+		Param string `json:"param,omitempty"`
+		Msg   string `json:"msg" example:"phone必须是一个有效的手机号"`
+	}
 )
 
 const (
 	BoolStrTrue    BoolStr = "true"
 	BoolStrFalse   BoolStr = "false"
 	defaultTagName         = "binding"
+	// embeddedFieldName 标记展开的嵌入结构体 生成字段路径时去掉
+	embeddedFieldName = "~"
 )
 
 var (
@@ -58,13 +71,73 @@ func (v *DefaultValidator) lazyInit() {
 	v.once.Do(func() {
 		v.validate = validator.New()
 		v.validate.SetTagName(defaultTagName)
+		v.validate.RegisterTagNameFunc(jsonTagName)
 		reg := v.validate.RegisterValidation
 		_ = reg("validBoolStr", validBoolStr)
 		_ = reg("phone", validPhone)
 		_ = reg("phoneOrEmpty", validPhoneOrEmpty)
+		_ = i18n.RegisterValidator(v.validate)
 	})
 }
 
+// FmtFieldErrors 将校验错误转换为字段级错误列表 trans为nil时使用原始错误信息
+func FmtFieldErrors(errs validator.ValidationErrors, trans ut.Translator) []FieldError {
+	list := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		msg := fe.Error()
+		if trans != nil {
+			msg = fe.Translate(trans)
+		}
+		list = append(list, FieldError{
+			Field: fieldPath(fe.Namespace()),
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+			Msg:   msg,
+		})
+	}
+	return list
+}
+
+// fieldPath 去掉命名空间中的顶层结构体名与嵌入结构体 Req.items[0].phone -> items[0].phone
+func fieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")
+	path := make([]string, 0, len(segments))
+	for _, segment := range segments[1:] {
+		if segment != embeddedFieldName {
+			path = append(path, segment)
+		}
+	}
+	if len(path) == 0 {
+		return namespace
+	}
+	return strings.Join(path, ".")
+}
+
+// jsonTagName 未设置json名称的嵌入结构体与 encoding/json 一致展开到外层 以 embeddedFieldName 标记
+func jsonTagName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		if isEmbeddedStruct(field) {
+			return embeddedFieldName
+		}
+		return field.Name
+	default:
+		return name
+	}
+}
+func isEmbeddedStruct(field reflect.StructField) bool {
+	if !field.Anonymous {
+		return false
+	}
+	typ := field.Type
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct
+}
 func kindOfData(data interface{}) reflect.Kind {
 	value := reflect.ValueOf(data)
 	valueType := value.Kind()
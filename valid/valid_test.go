@@ -0,0 +1,61 @@
+package valid
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type (
+	testPage struct {
+		Limit int `json:"limit" binding:"min=1"`
+	}
+	testItem struct {
+		Phone string `json:"phone" binding:"phone"`
+	}
+	testEmbedReq struct {
+		testPage
+		Items []testItem `json:"items" binding:"dive"`
+	}
+	testEmbedPtrReq struct {
+		*testPage
+	}
+	testTaggedReq struct {
+		testPage `json:"page"`
+	}
+	testNamedReq struct {
+		Page testPage `json:"page"`
+	}
+)
+
+func TestFmtFieldErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		obj  any
+		want []string
+	}{
+		{name: "embedded", obj: testEmbedReq{Items: []testItem{{Phone: "1"}}}, want: []string{"limit", "items[0].phone"}},
+		{name: "embedded pointer", obj: testEmbedPtrReq{testPage: &testPage{}}, want: []string{"limit"}},
+		{name: "embedded tagged", obj: testTaggedReq{}, want: []string{"page.limit"}},
+		{name: "named field", obj: testNamedReq{}, want: []string{"page.limit"}},
+	}
+	v := &DefaultValidator{}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var verrs validator.ValidationErrors
+			if err := v.ValidateStruct(tc.obj); !errors.As(err, &verrs) {
+				t.Fatalf("ValidateStruct() err = %v", err)
+			}
+			list := FmtFieldErrors(verrs, nil)
+			if len(list) != len(tc.want) {
+				t.Fatalf("FmtFieldErrors() = %+v, want fields %v", list, tc.want)
+			}
+			for i, fe := range list {
+				if fe.Field != tc.want[i] {
+					t.Fatalf("field %d = %q, want %q", i, fe.Field, tc.want[i])
+				}
+			}
+		})
+	}
+}